@@ -0,0 +1,132 @@
+package bundle
+
+import "fmt"
+import "os"
+import "path/filepath"
+import "regexp"
+import "strings"
+
+import "executer/config"
+import "executer/exec"
+import "executer/option"
+import "executer/report"
+import "executer/runner"
+import "executer/source"
+import "executer/util"
+
+// verify compiles `content` as `s` with the runner of `s` in a temporary directory, recording the compilations to `rep`.
+func verify(content string, s source.Source, o option.Options, c config.Config, base exec.Option, rep *report.Report) error {
+
+	var dir, err = os.MkdirTemp("", "executer-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var file = filepath.Join(dir, s.Base)
+
+	if s.Ext == "rs" {
+
+		//The runner expects a package containing the file, so we create one without the library as its dependency.
+		var manifest = util.ReadFileUnchecked(filepath.Join(base.WorkingDir(), "Cargo.toml"))
+		if c.RustLibrary != "" {
+			var name, err = rustLibraryName(config.ExpandHome(c.RustLibrary))
+			if err != nil {
+				return err
+			}
+			var dependencyRegexp = regexp.MustCompile(fmt.Sprintf(
+				`^\s*(%v|%v)\s*=`,
+				regexp.QuoteMeta(name),
+				regexp.QuoteMeta(strings.ReplaceAll(name, "_", "-")),
+			))
+			var l = make([]string, 0, len(manifest))
+			for _, line := range manifest {
+				if !dependencyRegexp.MatchString(line) {
+					l = append(l, line)
+				}
+			}
+			manifest = l
+		}
+		if err := os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte(strings.Join(manifest, "\n")), 0644); err != nil {
+			return err
+		}
+		if err := os.Mkdir(filepath.Join(dir, "src"), 0755); err != nil {
+			return err
+		}
+		file = filepath.Join(dir, "src", "main.rs")
+
+		//The artifacts are kept in the package of the source to be reused next time.
+		base.Env = append(base.Env, fmt.Sprintf("CARGO_TARGET_DIR=%v", filepath.Join(base.WorkingDir(), "target", "bundle")))
+		base.Dir = dir
+
+	}
+
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	o.Source = source.New(file)
+	o.IsOnlyCompileMode = true
+	o.IsOnlyExecuteMode = false
+	o.Mode = option.ModeRun
+	o.ShouldPutArtifactsInPlace = true //The directory is removed afterwards.
+	r, err := runner.Resolve(o, base)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range r.Steps {
+		var result, err = exec.Run(step)
+		if err != nil {
+			return err
+		}
+		rep.Add(step, result)
+		if result.ExitStatus != 0 {
+			return fmt.Errorf("`%v` exited with status %v", step.Command, result.ExitStatus)
+		}
+	}
+
+	return nil
+
+}
+
+// Run executes the `bundle` subcommand and returns the exit status.
+func Run(o option.Options, c config.Config, base exec.Option, rep *report.Report) int {
+
+	var s = o.Source
+
+	var content, err = func() (string, error) {
+		switch s.Ext {
+		case "c", "cpp":
+			var dirs = make([]string, 0, len(c.CppIncludeDirs))
+			for _, d := range c.CppIncludeDirs {
+				dirs = append(dirs, config.ExpandHome(d))
+			}
+			return Cpp(s.Path, dirs)
+		case "rs":
+			return Rust(s.Path, config.ExpandHome(c.RustLibrary))
+		}
+		return "", fmt.Errorf("unsupported file type: [ %v ]", s.Ext)
+	}()
+	if err != nil {
+		util.Eprintf("Failed to bundle: %v\n", err)
+		return base.ExitStatusWhenCompileError
+	}
+
+	if err := verify(content, s, o, c, base, rep); err != nil {
+		util.Eprintf("The bundled source doesn't compile: %v\n", err)
+		return base.ExitStatusWhenCompileError
+	}
+
+	if o.Output == "" {
+		fmt.Print(content)
+		return 0
+	}
+	if err := os.WriteFile(o.Output, []byte(content), 0644); err != nil {
+		util.Eprintf("Failed to write the bundled source: %v\n", err)
+		return 1
+	}
+	util.Eprintf("The bundled source is written to `%v`.\n", o.Output)
+	return 0
+
+}
@@ -0,0 +1,146 @@
+package bundle
+
+import "testing"
+import "fmt"
+import "os"
+import "path/filepath"
+import "strings"
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		var p = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_Cpp(t *testing.T) {
+
+	var dir = t.TempDir()
+	var lib = t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.cpp": "#include <iostream>\n#include \"a.hpp\"\n#include \"lib/b.hpp\"\nint main() {}\n",
+		"a.hpp":    "#pragma once\n#include \"lib/b.hpp\"\nint a;\n",
+	})
+	writeFiles(t, lib, map[string]string{
+		"lib/b.hpp": "#ifndef B\n#define B\nint b;\n#endif\n",
+	})
+
+	t.Run("success", func(t *testing.T) {
+
+		var ret, err = Cpp(filepath.Join(dir, "main.cpp"), []string{lib})
+		fmt.Println(ret)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var expected = "#include <iostream>\n#ifndef B\n#define B\nint b;\n#endif\nint a;\nint main() {}\n"
+		if ret != expected {
+			t.FailNow()
+		}
+
+	})
+
+	t.Run("without include guard", func(t *testing.T) {
+
+		var dir = t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"main.cpp": "#define X(n) int x##n;\n#include \"x.def\"\n#include \"x.def\"\n",
+			"x.def":    "X(__LINE__)\n",
+			"self.hpp": "#include \"self.hpp\"\n",
+		})
+
+		var ret, err = Cpp(filepath.Join(dir, "main.cpp"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Count(ret, "X(__LINE__)") != 2 {
+			t.Fatal(ret)
+		}
+
+		if _, err := Cpp(filepath.Join(dir, "self.hpp"), nil); (err == nil) || !strings.Contains(err.Error(), "recursively") {
+			t.Fatal(err)
+		}
+
+	})
+
+	t.Run("header not found", func(t *testing.T) {
+
+		var _, err = Cpp(filepath.Join(dir, "main.cpp"), nil)
+		fmt.Println(err)
+
+		if (err == nil) || !strings.Contains(err.Error(), "not found") {
+			t.Fatal(err)
+		}
+
+	})
+
+}
+
+func Test_Rust(t *testing.T) {
+
+	var dir = t.TempDir()
+	var lib = t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/main.rs":    "mod util;\nuse my_lib::math::gcd;\nfn main() {}\n",
+		"src/util.rs":    "pub mod io;\n",
+		"src/util/io.rs": "pub fn read() {}\n",
+	})
+	writeFiles(t, lib, map[string]string{
+		"Cargo.toml":           "[package]\nname = \"my-lib\"\n",
+		"src/lib.rs":           "pub mod math;\n",
+		"src/math/mod.rs":      "use crate::math::internal::abs;\nmod internal;\npub fn gcd() {}\n",
+		"src/math/internal.rs": "pub fn abs() {}\nmacro_rules! m { () => { $crate::math::gcd() } }\n",
+	})
+
+	t.Run("with library", func(t *testing.T) {
+
+		var ret, err = Rust(filepath.Join(dir, "src", "main.rs"), lib)
+		fmt.Println(ret)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var expected = `mod util {
+    pub mod io {
+        pub fn read() {}
+    }
+}
+use my_lib::math::gcd;
+fn main() {}
+
+#[allow(dead_code)]
+mod my_lib {
+    pub mod math {
+        use crate::my_lib::math::internal::abs;
+        mod internal {
+            pub fn abs() {}
+            macro_rules! m { () => { $crate::my_lib::math::gcd() } }
+        }
+        pub fn gcd() {}
+    }
+}
+`
+		if ret != expected {
+			t.FailNow()
+		}
+
+	})
+
+	t.Run("without library", func(t *testing.T) {
+
+		var ret, err = Rust(filepath.Join(dir, "src", "main.rs"), "")
+
+		if (err != nil) || strings.Contains(ret, "mod my_lib") {
+			t.Fatal(ret, err)
+		}
+
+	})
+
+}
@@ -0,0 +1,117 @@
+package bundle
+
+import "fmt"
+import "os"
+import "path/filepath"
+import "regexp"
+import "strings"
+
+import "executer/util"
+
+var (
+	cppIncludeRegexp    = regexp.MustCompile(`^\s*#\s*include\s*"([^"]+)"`)
+	cppPragmaOnceRegexp = regexp.MustCompile(`^\s*#\s*pragma\s+once\b`)
+	cppIfndefRegexp     = regexp.MustCompile(`^\s*#\s*ifndef\s+(\w+)`)
+	cppDefineRegexp     = regexp.MustCompile(`^\s*#\s*define\s+(\w+)`)
+	cppEndifRegexp      = regexp.MustCompile(`^\s*#\s*endif\b`)
+)
+
+// isIncludedOnce reports whether the header consisting of `lines` has `#pragma once` or an include guard
+// (i.e. `#ifndef X` and `#define X` first and `#endif` last, ignoring blank lines), so that the second and later
+// inclusions have no effect.
+func isIncludedOnce(lines []string) bool {
+
+	var l = make([]string, 0, len(lines))
+	for _, line := range lines {
+		if cppPragmaOnceRegexp.MatchString(line) {
+			return true
+		}
+		if strings.TrimSpace(line) != "" {
+			l = append(l, line)
+		}
+	}
+
+	if len(l) < 3 {
+		return false
+	}
+	var m1 = cppIfndefRegexp.FindStringSubmatch(l[0])
+	var m2 = cppDefineRegexp.FindStringSubmatch(l[1])
+	return (m1 != nil) && (m2 != nil) && (m1[1] == m2[1]) && cppEndifRegexp.MatchString(l[len(l)-1])
+
+}
+
+// Cpp inlines the local headers (i.e. `#include "..."`) of the C/C++ source `path`.
+// A header with `#pragma once` or an include guard is inlined only for the first inclusion, and the others every time.
+// Headers are searched in the directory of the including file and then in `includeDirs`.
+func Cpp(path string, includeDirs []string) (string, error) {
+
+	var ret = make([]string, 0)
+	var included = make(map[string]bool)  //the headers included once
+	var expanding = make(map[string]bool) //the files being expanded, to detect a recursive inclusion
+
+	var find = func(header string, dir string) string {
+		for _, d := range append([]string{dir}, includeDirs...) {
+			var p = filepath.Join(d, header)
+			if util.IsFile(p) {
+				var abs, _ = filepath.Abs(p)
+				return abs
+			}
+		}
+		return ""
+	}
+
+	var expand func(file string) error
+	expand = func(file string) error {
+
+		var b, err = os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var lines = strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+
+		if isIncludedOnce(lines) {
+			included[file] = true
+		}
+		expanding[file] = true
+		defer delete(expanding, file)
+
+		for _, line := range lines {
+
+			if cppPragmaOnceRegexp.MatchString(line) {
+				continue
+			}
+
+			var m = cppIncludeRegexp.FindStringSubmatch(line)
+			if m == nil {
+				ret = append(ret, line)
+				continue
+			}
+
+			var header = find(m[1], filepath.Dir(file))
+			if header == "" {
+				return fmt.Errorf("`%v` included from `%v` not found", m[1], file)
+			}
+			if included[header] {
+				continue
+			}
+			if expanding[header] {
+				return fmt.Errorf("`%v` includes itself recursively without an include guard", header)
+			}
+			if err := expand(header); err != nil {
+				return err
+			}
+
+		}
+
+		return nil
+
+	}
+
+	var abs, _ = filepath.Abs(path)
+	if err := expand(abs); err != nil {
+		return "", err
+	}
+
+	return strings.Join(ret, "\n") + "\n", nil
+
+}
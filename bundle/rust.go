@@ -0,0 +1,141 @@
+package bundle
+
+import "fmt"
+import "os"
+import "path/filepath"
+import "regexp"
+import "strings"
+
+import "executer/util"
+
+var (
+	rustModRegexp         = regexp.MustCompile(`^(\s*)((?:pub(?:\([^)]*\))?\s+)?)mod\s+(\w+)\s*;\s*$`)
+	rustCrateRegexp       = regexp.MustCompile(`(^|[^$\w])crate::`)
+	rustDollarCrateRegexp = regexp.MustCompile(`\$crate::`)
+	rustPackageNameRegexp = regexp.MustCompile(`(?m)^\s*name\s*=\s*"([^"]+)"`)
+)
+
+// expandRustModules replaces `mod foo;` in the Rust source `file` with `mod foo { ... }`.
+func expandRustModules(file string) ([]string, error) {
+
+	var b, err = os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	//the directory where the submodules of `file` live
+	var dir = filepath.Dir(file)
+	switch filepath.Base(file) {
+	case "main.rs", "lib.rs", "mod.rs":
+	default:
+		dir = filepath.Join(dir, strings.TrimSuffix(filepath.Base(file), ".rs"))
+	}
+
+	var ret = make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+
+		var m = rustModRegexp.FindStringSubmatch(line)
+		if m == nil {
+			ret = append(ret, line)
+			continue
+		}
+
+		var indent, visibility, name = m[1], m[2], m[3]
+		var submodule = filepath.Join(dir, name+".rs")
+		if !util.IsFile(submodule) {
+			submodule = filepath.Join(dir, name, "mod.rs")
+		}
+		if !util.IsFile(submodule) {
+			return nil, fmt.Errorf("module `%v` declared in `%v` not found", name, file)
+		}
+
+		var lines, err = expandRustModules(submodule)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, fmt.Sprintf("%v%vmod %v {", indent, visibility, name))
+		for _, l := range lines {
+			if l == "" {
+				ret = append(ret, l)
+			} else {
+				ret = append(ret, indent+"    "+l)
+			}
+		}
+		ret = append(ret, indent+"}")
+
+	}
+
+	return ret, nil
+
+}
+
+// rustLibraryName returns the name of the crate `dir` as used in paths (e.g. `my_lib` for `my-lib`).
+func rustLibraryName(dir string) (string, error) {
+	var b, err = os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return "", err
+	}
+	var m = rustPackageNameRegexp.FindStringSubmatch(string(b))
+	if m == nil {
+		return "", fmt.Errorf("package name not found in `%v`", filepath.Join(dir, "Cargo.toml"))
+	}
+	return strings.ReplaceAll(m[1], "-", "_"), nil
+}
+
+// Rust expands the modules of the Rust source `path`.
+// When `library` (the directory of a library crate) is not empty and the source uses the crate,
+// the crate is embedded as a module so that `use <crate>::...` keeps working.
+func Rust(path string, library string) (string, error) {
+
+	var abs, _ = filepath.Abs(path)
+	var lines, err = expandRustModules(abs)
+	if err != nil {
+		return "", err
+	}
+
+	if library == "" {
+		return strings.Join(lines, "\n") + "\n", nil
+	}
+
+	name, err := rustLibraryName(library)
+	if err != nil {
+		return "", err
+	}
+
+	var usesLibrary = false
+	var usageRegexp = regexp.MustCompile(fmt.Sprintf(`\b%v::`, regexp.QuoteMeta(name)))
+	var externCrateRegexp = regexp.MustCompile(fmt.Sprintf(`^\s*extern\s+crate\s+%v\s*;`, regexp.QuoteMeta(name)))
+	var ret = make([]string, 0, len(lines))
+	for _, line := range lines {
+		if externCrateRegexp.MatchString(line) {
+			usesLibrary = true
+			continue
+		}
+		if usageRegexp.MatchString(line) {
+			usesLibrary = true
+		}
+		ret = append(ret, line)
+	}
+
+	if usesLibrary {
+		libraryLines, err := expandRustModules(filepath.Join(library, "src", "lib.rs"))
+		if err != nil {
+			return "", err
+		}
+		ret = append(ret, "", "#[allow(dead_code)]", fmt.Sprintf("mod %v {", name))
+		for _, l := range libraryLines {
+			//Paths from the root of the library are now under the module.
+			l = rustDollarCrateRegexp.ReplaceAllString(l, fmt.Sprintf("$$crate::%v::", name))
+			l = rustCrateRegexp.ReplaceAllString(l, fmt.Sprintf("${1}crate::%v::", name))
+			if l == "" {
+				ret = append(ret, l)
+			} else {
+				ret = append(ret, "    "+l)
+			}
+		}
+		ret = append(ret, "}")
+	}
+
+	return strings.Join(ret, "\n") + "\n", nil
+
+}
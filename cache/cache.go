@@ -0,0 +1,332 @@
+package cache
+
+import "crypto/sha256"
+import "encoding/hex"
+import "encoding/json"
+import "errors"
+import "fmt"
+import "os"
+import osexec "os/exec"
+import "path/filepath"
+import "strings"
+import "time"
+
+import "golang.org/x/exp/slices"
+
+import "executer/exec"
+import "executer/util"
+
+// key consists of everything which affects the artifact of a compilation.
+type key struct {
+	Source   string   `json:"source"`   //SHA-256 of the contents
+	Compiler string   `json:"compiler"` //absolute path
+	Version  string   `json:"version"`
+	Argv     []string `json:"argv"`
+	Env      []string `json:"env,omitempty"`
+}
+
+// Entry is the record of a compilation stored in the cache.
+type Entry struct {
+	Source   string    `json:"source"`
+	Key      key       `json:"key"`
+	Artifact string    `json:"artifact"`
+	ModTime  time.Time `json:"mod_time"` //of the artifact, to detect that it's overwritten by others
+	Depfile  string    `json:"depfile,omitempty"`
+
+	Dependencies map[string]string `json:"dependencies,omitempty"` //SHA-256 of the local headers included (C/C++)
+}
+
+// Root returns the directory which contains all the cache entries.
+func Root() string {
+	return util.XDGDir("XDG_CACHE_HOME", ".cache")
+}
+
+// Dir returns the directory of the cache entry for `source`.
+func Dir(source string) string {
+	var sum = sha256.Sum256([]byte(source))
+	return filepath.Join(Root(), hex.EncodeToString(sum[:8]))
+}
+
+func entryFile(source string) string {
+	return filepath.Join(Dir(source), "entry.json")
+}
+
+// Entries returns the entries in the cache, keyed by their directories.
+// Directories without an entry (e.g. created by other programs sharing `$XDG_CACHE_HOME`) are ignored.
+func Entries() (map[string]Entry, error) {
+
+	var ret = make(map[string]Entry)
+
+	var l, err = filepath.Glob(filepath.Join(Root(), "*", "entry.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range l {
+		var b, err = os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var entry Entry
+		if err := json.Unmarshal(b, &entry); err != nil {
+			continue
+		}
+		ret[filepath.Dir(file)] = entry
+	}
+
+	return ret, nil
+
+}
+
+// versionEntry is the saved version of a compiler, which is valid until the executable is replaced.
+type versionEntry struct {
+	Executable string    `json:"executable"`
+	ModTime    time.Time `json:"mod_time"`
+	Size       int64     `json:"size"`
+	Version    string    `json:"version"`
+}
+
+func versionFile(executable string) string {
+	var sum = sha256.Sum256([]byte(executable))
+	return filepath.Join(Root(), "versions", hex.EncodeToString(sum[:8])+".json")
+}
+
+// version returns the first line printed by `compiler` for its version.
+// It's saved for the executable (with the symbolic links followed) and reused while its modification time and size
+// are the same, as the compilers such as GHC take a while to print it.
+func version(compiler string) (string, error) {
+
+	var executable, err = filepath.EvalSymlinks(compiler)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(executable)
+	if err != nil {
+		return "", err
+	}
+
+	var file = versionFile(executable)
+	if b, err := os.ReadFile(file); err == nil {
+		var e versionEntry
+		if (json.Unmarshal(b, &e) == nil) && (e.Executable == executable) && e.ModTime.Equal(info.ModTime()) && (e.Size == info.Size()) {
+			return e.Version, nil
+		}
+	}
+
+	var args = []string{"--version"}
+	if filepath.Base(compiler) == "go" {
+		args = []string{"version"}
+	}
+	b, err := osexec.Command(compiler, args...).Output()
+	if err != nil {
+		return "", err
+	}
+	var ret = strings.SplitN(strings.TrimSpace(string(b)), "\n", 2)[0]
+
+	var e = versionEntry{Executable: executable, ModTime: info.ModTime(), Size: info.Size(), Version: ret}
+	if b, err := json.Marshal(e); err == nil {
+		writeAtomically(file, b) //The version is got again next time if it fails.
+	}
+	return ret, nil
+
+}
+
+// outputOnlyEnv are the environment variables which affect only how the compiler prints, not the artifact.
+var outputOnlyEnv = []string{"CARGO_TERM_COLOR"}
+
+// keyEnv returns `env` without `outputOnlyEnv`.
+func keyEnv(env []string) []string {
+	var ret = make([]string, 0, len(env))
+	for _, e := range env {
+		if !slices.Contains(outputOnlyEnv, strings.SplitN(e, "=", 2)[0]) {
+			ret = append(ret, e)
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
+
+func newKey(o exec.Option) (key, error) {
+
+	var ret = key{Argv: o.Argv()[1:], Env: keyEnv(o.Env)}
+
+	var err error
+	if ret.Source, err = hashFile(o.Arguments[0]); err != nil {
+		return ret, err
+	}
+
+	if ret.Compiler, err = osexec.LookPath(o.Command); err != nil {
+		return ret, err
+	}
+	if ret.Compiler, err = filepath.Abs(ret.Compiler); err != nil {
+		return ret, err
+	}
+
+	if ret.Version, err = version(ret.Compiler); err != nil {
+		return ret, fmt.Errorf("failed to get the version of the compiler: %w", err)
+	}
+
+	return ret, nil
+
+}
+
+// lookup returns the current key of `o`, and a non-empty reason if the artifact needs to be rebuilt.
+func lookup(o exec.Option) (key, string, error) {
+
+	var k, err = newKey(o)
+	if err != nil {
+		return k, "", err
+	}
+
+	var b, err2 = os.ReadFile(entryFile(o.Arguments[0]))
+	if errors.Is(err2, os.ErrNotExist) {
+		return k, "no entry", nil
+	}
+	if err2 != nil {
+		return k, "", err2
+	}
+	var entry Entry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return k, "broken entry", nil
+	}
+
+	switch {
+	case entry.Artifact != o.Artifact:
+		return k, "the artifact is moved", nil
+	case entry.Key.Source != k.Source:
+		return k, "the source is modified", nil
+	case entry.Key.Compiler != k.Compiler:
+		return k, fmt.Sprintf("the compiler is changed from %v", entry.Key.Compiler), nil
+	case entry.Key.Version != k.Version:
+		return k, fmt.Sprintf("the version of the compiler is changed from [ %v ]", entry.Key.Version), nil
+	case !slices.Equal(entry.Key.Argv, k.Argv):
+		return k, fmt.Sprintf("the options are changed from %v", util.ToStringPretty(entry.Key.Argv)), nil
+	case !slices.Equal(entry.Key.Env, k.Env):
+		return k, fmt.Sprintf("the environment variables are changed from %v", util.ToStringPretty(entry.Key.Env)), nil
+	}
+
+	for file, hash := range entry.Dependencies {
+		if h, err := hashFile(file); (err != nil) || (h != hash) {
+			return k, fmt.Sprintf("the dependency `%v` is modified", file), nil
+		}
+	}
+
+	var info, err3 = os.Stat(o.Artifact)
+	if err3 != nil {
+		return k, "the artifact is removed", nil
+	}
+	if !info.ModTime().Equal(entry.ModTime) {
+		return k, "the artifact is overwritten", nil
+	}
+
+	return k, "", nil
+
+}
+
+func store(o exec.Option, k key) error {
+
+	var info, err = os.Stat(o.Artifact)
+	if err != nil {
+		return err
+	}
+
+	var entry = Entry{Source: o.Arguments[0], Key: k, Artifact: o.Artifact, ModTime: info.ModTime(), Depfile: o.Depfile}
+
+	var dependencies = imports(o.Arguments[0], o.WorkingDir())
+	if o.Depfile != "" {
+		var b, err = os.ReadFile(o.Depfile)
+		if err != nil {
+			return err
+		}
+		dependencies = append(dependencies, parseDepfile(string(b), o.WorkingDir())...)
+	}
+	if len(dependencies) != 0 {
+		entry.Dependencies = make(map[string]string)
+		for _, file := range dependencies {
+			if file == o.Arguments[0] {
+				continue
+			}
+			if entry.Dependencies[file], err = hashFile(file); err != nil {
+				return err
+			}
+		}
+	}
+
+	return write(entry)
+
+}
+
+// write saves `entry` as the entry of its source.
+func write(entry Entry) error {
+
+	var b, err = json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return writeAtomically(entryFile(entry.Source), b)
+
+}
+
+// writeAtomically writes `b` to `file` via a temporary file, so that the other processes never read it half-written.
+func writeAtomically(file string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	var tmp = file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// Record saves the entry of `artifact` built from `source` (e.g. the executable of a Go package in `Dir(source)`)
+// without the key, so that the artifact is known (e.g. to `clean`) although the compilation isn't cached.
+func Record(source string, artifact string) error {
+	return write(Entry{Source: source, Artifact: artifact})
+}
+
+// Execute is `exec.Execute` which skips the compilation when the artifact of the same inputs exists.
+// Only the compilations with `o.Artifact` are cached, and `isForced` disables the cache.
+// The last return value is `true` when the compilation is skipped.
+func Execute(o exec.Option, isForced bool) (exec.Result, int, bool) {
+
+	if !o.IsCompileMode || (o.Artifact == "") || (len(o.Arguments) == 0) {
+		var result, exitStatus = exec.Execute(o)
+		return result, exitStatus, false
+	}
+
+	var k, reason, err = lookup(o)
+	switch {
+	case err != nil:
+		util.DebugPrint(fmt.Sprintf("Build cache disabled: %v", err), o.IsDebugMode)
+	case isForced:
+		util.DebugPrint("Build cache miss: forced to rebuild", o.IsDebugMode)
+	case reason != "":
+		util.DebugPrint(fmt.Sprintf("Build cache miss: %v", reason), o.IsDebugMode)
+	default:
+		util.DebugPrint(fmt.Sprintf("Build cache hit: %v", o.Artifact), o.IsDebugMode)
+		return exec.Result{}, 0, true
+	}
+
+	for _, file := range []string{o.Artifact, o.Depfile} {
+		if file == "" {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			fmt.Fprintf(o.ErrWriter(), "Failed to create the directory for the artifact: %v\n", err)
+			return exec.Result{}, o.ExitStatusWhenCompileError, false
+		}
+	}
+
+	var result, exitStatus = exec.Execute(o)
+	if (err == nil) && (exitStatus == 0) {
+		if err := store(o, k); err != nil {
+			util.DebugPrint(fmt.Sprintf("Failed to store the build cache: %v", err), o.IsDebugMode)
+		}
+	}
+	return result, exitStatus, false
+
+}
@@ -0,0 +1,143 @@
+package cache
+
+import "os"
+import "path/filepath"
+import "strings"
+import "testing"
+import "time"
+
+import "executer/exec"
+
+func Test_Execute(t *testing.T) {
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var dir = t.TempDir()
+	var src = filepath.Join(dir, "a.txt")
+	var artifact = filepath.Join(dir, "a.out")
+	if err := os.WriteFile(src, []byte("1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	//`cp` stands in for a compiler.
+	var o = exec.Option{
+		IsCompileMode: true,
+		Command:       "cp",
+		Arguments:     []string{src, artifact},
+		Artifact:      artifact,
+	}
+
+	var check = func(t *testing.T, o exec.Option, isForced bool, expected bool) {
+		t.Helper()
+		var _, exitStatus, isCached = Execute(o, isForced)
+		if (exitStatus != 0) || (isCached != expected) {
+			t.Fatal(exitStatus, isCached)
+		}
+	}
+
+	t.Run("first build", func(t *testing.T) {
+		check(t, o, false, false)
+	})
+
+	t.Run("same inputs", func(t *testing.T) {
+		check(t, o, false, true)
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		check(t, o, true, false)
+	})
+
+	t.Run("options changed", func(t *testing.T) {
+		var p = o
+		p.CompileOptions = []string{"-p"}
+		check(t, p, false, false)
+		check(t, o, false, false)
+	})
+
+	t.Run("environment changed", func(t *testing.T) {
+		var p = o
+		p.Env = []string{"A=1"}
+		check(t, p, false, false)
+		check(t, p, false, true)
+		check(t, o, false, false)
+		p.Env = []string{"CARGO_TERM_COLOR=always"} //affects only the output
+		check(t, p, false, true)
+	})
+
+	t.Run("source modified", func(t *testing.T) {
+		if err := os.WriteFile(src, []byte("2"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		check(t, o, false, false)
+		check(t, o, false, true)
+	})
+
+	t.Run("dependency modified", func(t *testing.T) {
+		var header = filepath.Join(dir, "a.h")
+		var depfile = filepath.Join(dir, "a.d")
+		if err := os.WriteFile(header, []byte("1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(depfile, []byte("a.out: a.txt a.h\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var p = o
+		p.Dir = dir
+		p.Depfile = depfile
+		check(t, p, true, false)
+		check(t, p, false, true)
+		if err := os.WriteFile(header, []byte("2"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		check(t, p, false, false)
+		check(t, p, false, true)
+	})
+
+	t.Run("artifact removed", func(t *testing.T) {
+		os.Remove(artifact)
+		check(t, o, false, false)
+	})
+
+	t.Run("not cacheable", func(t *testing.T) {
+		var p = o
+		p.Artifact = ""
+		check(t, p, false, false)
+		check(t, p, false, false)
+	})
+
+}
+
+func Test_version(t *testing.T) {
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	//The compiler counts how many times it's run.
+	var dir = t.TempDir()
+	var compiler = filepath.Join(dir, "cc")
+	var count = filepath.Join(dir, "count")
+	if err := os.WriteFile(compiler, []byte("#!/bin/sh\necho x >> "+count+"\necho 'cc 1.0'\necho 'more'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var check = func(t *testing.T, expected int) {
+		t.Helper()
+		var v, err = version(compiler)
+		if (err != nil) || (v != "cc 1.0") {
+			t.Fatal(v, err)
+		}
+		var b, _ = os.ReadFile(count)
+		if n := strings.Count(string(b), "x"); n != expected {
+			t.Fatal(n)
+		}
+	}
+
+	check(t, 1)
+	check(t, 1)
+
+	var later = time.Now().Add(time.Hour)
+	if err := os.Chtimes(compiler, later, later); err != nil {
+		t.Fatal(err)
+	}
+	check(t, 2)
+
+}
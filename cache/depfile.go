@@ -0,0 +1,78 @@
+package cache
+
+import "crypto/sha256"
+import "encoding/hex"
+import "os"
+import "path/filepath"
+import "strings"
+
+// hashFile returns SHA-256 of the contents of `file`.
+func hashFile(file string) (string, error) {
+	var b, err = os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	var sum = sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// parseDepfile returns the prerequisites listed in the Makefile-style dependency file written by `-MMD`,
+// e.g. `main.out: main.cpp lib.hpp \` followed by the continuation lines.
+// Relative paths are resolved against `dir`, and phony targets (`-MP`) are ignored.
+func parseDepfile(content string, dir string) []string {
+
+	var ret = make([]string, 0)
+
+	var isPrerequisite = false //after the colon of the first rule
+	var word strings.Builder
+	var flush = func() {
+		if word.Len() == 0 {
+			return
+		}
+		var w = word.String()
+		word.Reset()
+		if !filepath.IsAbs(w) {
+			w = filepath.Join(dir, w)
+		}
+		ret = append(ret, w)
+	}
+
+	for i := 0; i < len(content); i++ {
+		var c = content[i]
+		switch {
+		case (c == '\\') && (i+1 < len(content)) && (content[i+1] == '\n'): //continuation
+			i++
+			if isPrerequisite {
+				flush()
+			}
+		case (c == '\\') && (i+1 < len(content)) && ((content[i+1] == ' ') || (content[i+1] == '\\') || (content[i+1] == '#')):
+			i++
+			word.WriteByte(content[i])
+		case (c == '$') && (i+1 < len(content)) && (content[i+1] == '$'):
+			i++
+			word.WriteByte('$')
+		case (c == ':') && !isPrerequisite && ((i+1 == len(content)) || (content[i+1] == ' ') || (content[i+1] == '\n')):
+			word.Reset() //target
+			isPrerequisite = true
+		case (c == ' ') || (c == '\t'):
+			if isPrerequisite {
+				flush()
+			} else {
+				word.Reset()
+			}
+		case c == '\n':
+			if isPrerequisite {
+				flush()
+				return ret //The rest is the phony targets.
+			}
+		default:
+			word.WriteByte(c)
+		}
+	}
+	if isPrerequisite {
+		flush()
+	}
+
+	return ret
+
+}
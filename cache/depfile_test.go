@@ -0,0 +1,27 @@
+package cache
+
+import "testing"
+
+import "golang.org/x/exp/slices"
+
+func Test_parseDepfile(t *testing.T) {
+
+	var tests = []struct {
+		content  string
+		expected []string
+	}{
+		{"a.out: a.cpp\n", []string{"/w/a.cpp"}},
+		{"/x/a.out: /w/a.cpp \\\n /w/lib.hpp \\\n sub/b.hpp\n", []string{"/w/a.cpp", "/w/lib.hpp", "/w/sub/b.hpp"}},
+		{"a.out: a.cpp my\\ lib.hpp $$x.h\n", []string{"/w/a.cpp", "/w/my lib.hpp", "/w/$x.h"}},
+		{"a.out: a.cpp lib.hpp\n\nlib.hpp:\n", []string{"/w/a.cpp", "/w/lib.hpp"}},
+		{"", []string{}},
+	}
+
+	for _, test := range tests {
+		var ret = parseDepfile(test.content, "/w")
+		if !slices.Equal(ret, test.expected) {
+			t.Fatalf("%q %v", test.content, ret)
+		}
+	}
+
+}
@@ -0,0 +1,74 @@
+package cache
+
+import "os"
+import "path/filepath"
+import "regexp"
+import "strings"
+
+// The compilers of these languages build the local modules imported by the source as well, but write no depfile.
+var (
+	haskellImportRegexp = regexp.MustCompile(`(?m)^import\s+(?:qualified\s+)?([A-Z][\w.]*)`)
+	dartImportRegexp    = regexp.MustCompile(`(?m)^\s*(?:import|export|part)\s+['"]([^'"]+)['"]`)
+)
+
+// imports returns the local files which `source` imports directly or indirectly, in the order they're found.
+// A Haskell module `A.B` is searched for as `A/B.hs` in the directory of the importing file and in `dir`,
+// where GHC runs, and a Dart import is a path relative to the importing file unless it's `dart:` or `package:`.
+// It returns nil for the other languages.
+func imports(source string, dir string) []string {
+
+	var find func(file string) []string
+	switch filepath.Ext(source) {
+	case ".hs":
+		find = func(file string) []string {
+			var b, err = os.ReadFile(file)
+			if err != nil {
+				return nil
+			}
+			var ret = make([]string, 0)
+			for _, m := range haskellImportRegexp.FindAllStringSubmatch(string(b), -1) {
+				var rel = filepath.FromSlash(strings.ReplaceAll(m[1], ".", "/")) + ".hs"
+				ret = append(ret, filepath.Join(filepath.Dir(file), rel), filepath.Join(dir, rel))
+			}
+			return ret
+		}
+	case ".dart":
+		find = func(file string) []string {
+			var b, err = os.ReadFile(file)
+			if err != nil {
+				return nil
+			}
+			var ret = make([]string, 0)
+			for _, m := range dartImportRegexp.FindAllStringSubmatch(string(b), -1) {
+				if strings.Contains(m[1], ":") {
+					continue
+				}
+				ret = append(ret, filepath.Join(filepath.Dir(file), filepath.FromSlash(m[1])))
+			}
+			return ret
+		}
+	default:
+		return nil
+	}
+
+	var ret = make([]string, 0)
+	var visited = map[string]bool{source: true}
+	var queue = []string{source}
+	for len(queue) != 0 {
+		var file = queue[0]
+		queue = queue[1:]
+		for _, f := range find(file) {
+			if visited[f] {
+				continue
+			}
+			visited[f] = true
+			if info, err := os.Stat(f); (err != nil) || info.IsDir() {
+				continue //a module of a package, or one not found
+			}
+			ret = append(ret, f)
+			queue = append(queue, f)
+		}
+	}
+	return ret
+
+}
@@ -0,0 +1,54 @@
+package cache
+
+import "os"
+import "path/filepath"
+import "testing"
+
+import "golang.org/x/exp/slices"
+
+func Test_imports(t *testing.T) {
+
+	var dir = t.TempDir()
+	var files = map[string]string{
+		"Main.hs":         "module Main where\nimport qualified Data.Map as M\nimport MyLib\nimport Sub.Util (f)\nmain = pure ()\n",
+		"MyLib.hs":        "module MyLib where\nimport Sub.Util\n",
+		"Sub/Util.hs":     "module Sub.Util where\n",
+		"main.dart":       "import 'dart:io';\nimport 'package:path/path.dart';\nimport 'lib/a.dart';\nexport \"b.dart\";\n",
+		"lib/a.dart":      "part 'a_part.dart';\nimport '../b.dart';\n",
+		"lib/a_part.dart": "",
+		"b.dart":          "",
+		"main.cpp":        "#include \"MyLib.hs\"\n",
+	}
+	for file, content := range files {
+		var path = filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tests = []struct {
+		source   string
+		expected []string
+	}{
+		{"Main.hs", []string{"MyLib.hs", "Sub/Util.hs"}},
+		{"main.dart", []string{"lib/a.dart", "b.dart", "lib/a_part.dart"}},
+		{"main.cpp", nil},
+	}
+
+	for _, test := range tests {
+		var ret = imports(filepath.Join(dir, test.source), dir)
+		var expected []string
+		for _, file := range test.expected {
+			expected = append(expected, filepath.Join(dir, file))
+		}
+		slices.Sort(ret)
+		slices.Sort(expected)
+		if !slices.Equal(ret, expected) {
+			t.Fatal(test.source, ret)
+		}
+	}
+
+}
@@ -0,0 +1,195 @@
+package clean
+
+import "fmt"
+import "io/fs"
+import "os"
+import "path/filepath"
+import "sort"
+import "strings"
+import "time"
+
+import "executer/cache"
+import "executer/history"
+import "executer/option"
+import "executer/source"
+import "executer/util"
+
+// outputs returns the files which the runner of `s` may create next to it without recording them in the cache,
+// e.g. the classes written by `javac`. Only the files derived from the name of `s` are returned.
+func outputs(s source.Source) []string {
+
+	var ret = make([]string, 0)
+
+	switch s.Ext {
+	case "c", "cpp":
+		ret = append(ret, s.PathWoExt+".out", s.PathWoExt+".d")
+	case "go", "dart":
+		ret = append(ret, s.PathWoExt+".out")
+	case "hs":
+		ret = append(ret, s.PathWoExt+".out", s.PathWoExt+".hi", s.PathWoExt+".o")
+	case "java":
+		ret = append(ret, s.PathWoExt+".class")
+		var l, _ = filepath.Glob(filepath.Join(s.Dir, glob(s.Name)+"$*.class")) //nested classes
+		ret = append(ret, l...)
+	case "ts":
+		if !strings.HasSuffix(s.Base, "test.ts") {
+			ret = append(ret, filepath.Join(s.Dir, "target", s.Name+".js"))
+		}
+	}
+
+	return ret
+
+}
+
+// glob escapes the metacharacters of `filepath.Match` in `s`.
+func glob(s string) string {
+	var r = strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`)
+	return r.Replace(s)
+}
+
+// lastRuns returns when the last run of each source recorded in the history finished.
+func lastRuns() (map[string]time.Time, error) {
+	var entries, err = history.Load()
+	if err != nil {
+		return nil, err
+	}
+	var ret = make(map[string]time.Time)
+	for _, e := range entries {
+		var end = e.Time.Add(time.Duration(e.DurationSeconds * float64(time.Second)))
+		if end.After(ret[e.Options.Source.Path]) {
+			ret[e.Options.Source.Path] = end
+		}
+	}
+	return ret, nil
+}
+
+// isUnder reports whether `path` is `root` or in the tree of `root`.
+func isUnder(path string, root string) bool {
+	var rel, err = filepath.Rel(root, path)
+	return (err == nil) && (rel != "..") && !strings.HasPrefix(rel, "../")
+}
+
+// isUnchanged reports whether `file` exists as it was recorded at `modTime`, i.e. it isn't overwritten by others.
+func isUnchanged(file string, modTime time.Time) bool {
+	var info, err = os.Stat(file)
+	return (err == nil) && !info.IsDir() && info.ModTime().Equal(modTime)
+}
+
+// isNotNewer reports whether `file` exists and isn't modified after `t`, i.e. by others after executer ran.
+func isNotNewer(file string, t time.Time) bool {
+	var info, err = os.Stat(file)
+	return (err == nil) && !info.IsDir() && !info.ModTime().After(t)
+}
+
+// Find returns the files and the cache directories created by executer for `target`, which is either a source or
+// a directory containing sources:
+//   - the cache directories recorded for the sources, and the artifacts recorded outside of them
+//     (i.e. by `--artifacts-in-place`) unless they're overwritten,
+//   - the outputs of the runners next to the sources (e.g. `.class`) if the source has been run by executer
+//     (as recorded in the history) and they aren't modified after the last run.
+//
+// A Go file is taken as its package as well, whose executable is recorded for the directory.
+func Find(target string) ([]string, error) {
+
+	var found = make(map[string]bool)
+
+	var root, err = filepath.Abs(target)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		return nil, err
+	}
+	for dir, entry := range entries {
+		var isTarget = isUnder(entry.Source, root) || ((filepath.Ext(root) == ".go") && (entry.Source == filepath.Dir(root)))
+		if (entry.Source == "") || !isTarget {
+			continue
+		}
+		found[dir] = true
+		if !isUnder(entry.Artifact, dir) && isUnchanged(entry.Artifact, entry.ModTime) {
+			found[entry.Artifact] = true
+			if (entry.Depfile != "") && !isUnder(entry.Depfile, dir) && util.IsFile(entry.Depfile) {
+				found[entry.Depfile] = true
+			}
+		}
+	}
+
+	var sources = []string{root}
+	if info.IsDir() {
+		sources = make([]string, 0)
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if (path != root) && (strings.HasPrefix(d.Name(), ".") || (d.Name() == "node_modules")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			sources = append(sources, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	runs, err := lastRuns()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range sources {
+		var end, ok = runs[path]
+		if !ok {
+			continue
+		}
+		for _, file := range outputs(source.New(path)) {
+			if isNotNewer(file, end) {
+				found[file] = true
+			}
+		}
+	}
+
+	var ret = make([]string, 0, len(found))
+	for file := range found {
+		ret = append(ret, file)
+	}
+	sort.Strings(ret)
+	return ret, nil
+
+}
+
+// Run executes the `clean` subcommand and returns the exit status.
+func Run(o option.Options) int {
+
+	var l, err = Find(o.Source.Path)
+	if err != nil {
+		util.Eprintf("Failed to find the artifacts: %v\n", err)
+		return 1
+	}
+
+	if o.IsDryRun {
+		for _, file := range l {
+			fmt.Println(file)
+		}
+		return 0
+	}
+
+	var ret = 0
+	for _, file := range l {
+		if err := os.RemoveAll(file); err != nil {
+			util.Eprintf("Failed to remove: %v\n", err)
+			ret = 1
+			continue
+		}
+		util.Eprintf("Removed `%v`.\n", file)
+	}
+	return ret
+
+}
@@ -0,0 +1,161 @@
+package clean
+
+import "os"
+import "path/filepath"
+import "testing"
+import "time"
+
+import "golang.org/x/exp/slices"
+
+import "executer/cache"
+import "executer/exec"
+import "executer/history"
+import "executer/option"
+import "executer/source"
+
+func Test_Find(t *testing.T) {
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	var dir = t.TempDir()
+	var files = []string{
+		"a.cpp",
+		"b.cpp", "b.out", "b.d",
+		"c.cpp", "c.out", //overwritten by others
+		"sub/d.hs", "sub/d.hi", "sub/d.o", "sub/d.out", //not recorded
+		"sub/main.go",
+		"e.d", "e.out", //a D source and a file without a source
+	}
+	for _, file := range files {
+		var path = filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//compile "compiles" `source` into `artifact` with `cp`, which stores the cache entry.
+	var compile = func(source string, artifact string, depfile string) {
+		var _, exitStatus, _ = cache.Execute(exec.Option{
+			IsCompileMode: true,
+			Command:       "cp",
+			Arguments:     []string{filepath.Join(dir, source), artifact},
+			Artifact:      artifact,
+			Depfile:       depfile,
+		}, false)
+		if exitStatus != 0 {
+			t.FailNow()
+		}
+	}
+	compile("a.cpp", filepath.Join(cache.Dir(filepath.Join(dir, "a.cpp")), "a.out"), "")
+	compile("b.cpp", filepath.Join(dir, "b.out"), filepath.Join(dir, "b.d"))
+	compile("c.cpp", filepath.Join(dir, "c.out"), "")
+	if err := os.WriteFile(filepath.Join(dir, "c.out"), []byte("overwritten"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	//the executable of the Go package in `sub/`
+	if err := cache.Record(filepath.Join(dir, "sub"), filepath.Join(cache.Dir(filepath.Join(dir, "sub")), "sub")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("directory", func(t *testing.T) {
+		var l, err = Find(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expected = []string{
+			cache.Dir(filepath.Join(dir, "a.cpp")),
+			cache.Dir(filepath.Join(dir, "b.cpp")),
+			cache.Dir(filepath.Join(dir, "c.cpp")),
+			cache.Dir(filepath.Join(dir, "sub")),
+			filepath.Join(dir, "b.d"),
+			filepath.Join(dir, "b.out"),
+		}
+		slices.Sort(expected)
+		if !slices.Equal(l, expected) {
+			t.Fatal(l)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		var l, err = Find(filepath.Join(dir, "b.cpp"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(l) != 3 {
+			t.Fatal(l)
+		}
+	})
+
+	t.Run("outputs", func(t *testing.T) {
+
+		var dir = t.TempDir()
+		var files = []string{
+			"A.java", "A.class", "A$Inner.class",
+			"t.ts", "target/t.js",
+			"h.hs", "h.hi", "h.o", "h.out",
+			"c.cpp", "c.out", "c.d",
+			"g.go", "g.out",
+			"B.java", "B.class", //not run
+			"u.ts", "target/u.js", //modified after the run
+		}
+		for _, file := range files {
+			var path = filepath.Join(dir, file)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, file := range []string{"A.java", "t.ts", "h.hs", "c.cpp", "g.go", "u.ts"} {
+			var e = history.Entry{Time: time.Now().Add(time.Second), Options: option.Options{Source: source.New(filepath.Join(dir, file))}}
+			if err := history.Append(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var later = time.Now().Add(time.Hour)
+		if err := os.Chtimes(filepath.Join(dir, "target/u.js"), later, later); err != nil {
+			t.Fatal(err)
+		}
+
+		var l, err = Find(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expected = []string{
+			"A.class", "A$Inner.class",
+			"target/t.js",
+			"h.hi", "h.o", "h.out",
+			"c.out", "c.d",
+			"g.out",
+		}
+		for i := range expected {
+			expected[i] = filepath.Join(dir, expected[i])
+		}
+		slices.Sort(expected)
+		if !slices.Equal(l, expected) {
+			t.Fatal(l)
+		}
+
+		if l, err := Find(filepath.Join(dir, "A.java")); (err != nil) || (len(l) != 2) {
+			t.Fatal(l, err)
+		}
+
+	})
+
+	t.Run("Go file", func(t *testing.T) {
+		var l, err = Find(filepath.Join(dir, "sub/main.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(l, []string{cache.Dir(filepath.Join(dir, "sub"))}) {
+			t.Fatal(l)
+		}
+	})
+
+}
@@ -0,0 +1,166 @@
+package config
+
+import "encoding/json"
+import "errors"
+import "fmt"
+import "os"
+import "path/filepath"
+import "regexp"
+import "sort"
+import "strings"
+
+import "executer/option"
+import "executer/util"
+
+// Profile is a named set of settings applied to the sources matching `Paths`.
+type Profile struct {
+	Name        string              `json:"name"`
+	Paths       []string            `json:"paths"`        //globs (`**` matches any number of directories)
+	CompileArgs map[string][]string `json:"compile_args"` //prepended to `--compile-args` for the sources of each extension
+	Env         map[string]string   `json:"env"`
+	Mode        string              `json:"mode"`     //default of `--mode`
+	TestDir     string              `json:"test_dir"` //default of `--test-dir`
+}
+
+type Config struct {
+	Profiles       []Profile `json:"profiles"`
+	CppIncludeDirs []string  `json:"cpp_include_dirs"` //for `bundle`
+	RustLibrary    string    `json:"rust_library"`     //directory of the library crate for `bundle`
+}
+
+// defaultProfiles are used when the configuration file doesn't define any profile.
+var defaultProfiles = []Profile{
+	{
+		Name:  "atcoder",
+		Paths: []string{"**/atcoder/**/main.rs"}, //the packages of cargo-compete
+		Env:   map[string]string{"RUST_BACKTRACE": "0"},
+		Mode:  option.ModeTest,
+	},
+}
+
+// File returns the path to the configuration file.
+func File() string {
+	return filepath.Join(util.XDGDir("XDG_CONFIG_HOME", ".config"), "config.json")
+}
+
+// Load reads the configuration file. The default configuration is returned if the file doesn't exist.
+func Load() (Config, error) {
+
+	var ret = Config{}
+
+	var b, err = os.ReadFile(File())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return ret, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &ret); err != nil {
+			return ret, fmt.Errorf("invalid configuration file `%v`: %w", File(), err)
+		}
+	}
+
+	if ret.Profiles == nil {
+		ret.Profiles = defaultProfiles
+	}
+
+	for _, p := range ret.Profiles {
+		if p.Name == "" {
+			return ret, fmt.Errorf("invalid configuration file `%v`: a profile without name", File())
+		}
+		if (p.Mode != "") && (p.Mode != option.ModeRun) && (p.Mode != option.ModeTest) {
+			return ret, fmt.Errorf("invalid configuration file `%v`: unknown mode `%v` of the profile `%v`", File(), p.Mode, p.Name)
+		}
+	}
+
+	return ret, nil
+
+}
+
+// ExpandHome replaces the leading `~/` of `path` with the home directory.
+func ExpandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		var home, _ = os.UserHomeDir()
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}
+
+// globToRegexp converts a path glob to a regular expression.
+// A relative glob matches paths in any directory.
+func globToRegexp(glob string) *regexp.Regexp {
+
+	glob = ExpandHome(glob)
+	if !strings.HasPrefix(glob, "/") {
+		glob = "**/" + glob
+	}
+
+	var ret = "^"
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			ret += "(.*/)?"
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			ret += ".*"
+			i++
+		case glob[i] == '*':
+			ret += "[^/]*"
+		case glob[i] == '?':
+			ret += "[^/]"
+		default:
+			ret += regexp.QuoteMeta(glob[i : i+1])
+		}
+	}
+	ret += "$"
+
+	return regexp.MustCompile(ret)
+
+}
+
+// Select returns the profile named `name`, or the first profile matching `path` if `name` is empty.
+func (c Config) Select(name string, path string) (Profile, bool, error) {
+
+	if name != "" {
+		for _, p := range c.Profiles {
+			if p.Name == name {
+				return p, true, nil
+			}
+		}
+		return Profile{}, false, fmt.Errorf("unknown profile: [ %v ]", name)
+	}
+
+	for _, p := range c.Profiles {
+		for _, glob := range p.Paths {
+			if globToRegexp(glob).MatchString(path) {
+				return p, true, nil
+			}
+		}
+	}
+
+	return Profile{}, false, nil
+
+}
+
+// Apply reflects the profile to `o`. The options specified in the command line take precedence.
+func (p Profile) Apply(o option.Options) option.Options {
+	o.CompileArgs = append(append([]string{}, p.CompileArgs[o.Source.Ext]...), o.CompileArgs...)
+	if o.Mode == "" {
+		o.Mode = p.Mode
+	}
+	if (o.TestDir == "") && (p.TestDir != "") {
+		o.TestDir = p.TestDir
+		if !filepath.IsAbs(o.TestDir) {
+			o.TestDir = filepath.Join(o.Source.Dir, o.TestDir)
+		}
+	}
+	return o
+}
+
+// Environ returns the environment variables of the profile in the form of `key=value`.
+func (p Profile) Environ() []string {
+	var ret = make([]string, 0, len(p.Env))
+	for k, v := range p.Env {
+		ret = append(ret, fmt.Sprintf("%v=%v", k, v))
+	}
+	sort.Strings(ret)
+	return ret
+}
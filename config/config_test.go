@@ -0,0 +1,115 @@
+package config
+
+import "testing"
+import "os"
+import "path/filepath"
+
+import "golang.org/x/exp/slices"
+
+import "executer/option"
+import "executer/source"
+
+func Test_globToRegexp(t *testing.T) {
+
+	var tests = []struct {
+		glob    string
+		path    string
+		matches bool
+	}{
+		{"**/atcoder/**", "/home/user/atcoder/abc300/a/src/main.rs", true},
+		{"**/atcoder/**", "/home/user/codeforces/1800/a.cpp", false},
+		{"**/atcoder/**/main.rs", "/home/user/atcoder/abc300/a/src/main.rs", true},
+		{"**/atcoder/**/main.rs", "/home/user/atcoder/abc300/a/src/lib.rs", false},
+		{"atcoder/*.cpp", "/home/user/atcoder/a.cpp", true},
+		{"atcoder/*.cpp", "/home/user/atcoder/abc/a.cpp", false},
+		{"/home/*/yukicoder/**", "/home/user/yukicoder/1/a.py", true},
+		{"/home/*/yukicoder/**", "/root/home/user/yukicoder/1/a.py", false},
+		{"a?.go", "/x/ab.go", true},
+		{"a.go", "/x/a_go", false},
+	}
+
+	for _, test := range tests {
+		if globToRegexp(test.glob).MatchString(test.path) != test.matches {
+			t.Fatal(test)
+		}
+	}
+
+}
+
+func Test_Load(t *testing.T) {
+
+	t.Run("default", func(t *testing.T) {
+
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		var c, err = Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var p, ok, _ = c.Select("", "/home/user/atcoder/abc300/a/src/main.rs")
+		if !(ok && (p.Name == "atcoder") && slices.Equal(p.Environ(), []string{"RUST_BACKTRACE=0"})) {
+			t.Fatal(p)
+		}
+
+		if p, ok, _ := c.Select("", "/home/user/atcoder/abc300/a.cpp"); ok {
+			t.Fatal(p)
+		}
+
+	})
+
+	t.Run("file", func(t *testing.T) {
+
+		var dir = t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", dir)
+		os.MkdirAll(filepath.Join(dir, "executer"), 0755)
+		os.WriteFile(File(), []byte(`{"profiles": [
+			{"name": "codeforces", "paths": ["**/codeforces/**"], "compile_args": {"cpp": ["-O2"]}, "test_dir": "tests"},
+			{"name": "release", "compile_args": {"rs": ["--release"]}}
+		]}`), 0644)
+
+		var c, err = Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok, _ := c.Select("", "/home/user/atcoder/a.cpp"); ok {
+			t.Fatal("The default profiles should be replaced.")
+		}
+
+		var p, ok, _ = c.Select("", "/home/user/codeforces/a.cpp")
+		if !(ok && (p.Name == "codeforces")) {
+			t.Fatal(p)
+		}
+		var o = p.Apply(option.Options{Source: source.New("/home/user/codeforces/a.cpp"), CompileArgs: []string{"-g"}})
+		if !(slices.Equal(o.CompileArgs, []string{"-O2", "-g"}) && (o.TestDir == "/home/user/codeforces/tests")) {
+			t.Fatal(o)
+		}
+		if o = p.Apply(option.Options{Source: source.New("/home/user/codeforces/a.py")}); len(o.CompileArgs) != 0 {
+			t.Fatal(o)
+		}
+
+		if p, ok, err := c.Select("release", "/home/user/codeforces/a.cpp"); !(ok && (err == nil) && (p.Name == "release")) {
+			t.Fatal(p, err)
+		}
+
+		if _, _, err := c.Select("contest", "/home/user/codeforces/a.cpp"); err == nil {
+			t.FailNow()
+		}
+
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+
+		var dir = t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", dir)
+		os.MkdirAll(filepath.Join(dir, "executer"), 0755)
+		os.WriteFile(File(), []byte(`{"profiles": [{"name": "a", "mode": "bench"}]}`), 0644)
+
+		if _, err := Load(); err == nil {
+			t.FailNow()
+		}
+
+	})
+
+}
@@ -0,0 +1,360 @@
+package daemon
+
+import "bufio"
+import "context"
+import "encoding/json"
+import "fmt"
+import "io"
+import "net"
+import "os"
+import "os/signal"
+import "path/filepath"
+import "sort"
+import "strings"
+import "sync"
+import "syscall"
+import "time"
+
+import "executer/option"
+import "executer/protocol"
+import "executer/util"
+
+// maxFinishedRuns is the number of finished runs kept for `status` and `list`.
+const maxFinishedRuns = 100
+
+// DefaultSocket returns the socket which the daemon listens on when no socket is specified.
+func DefaultSocket() string {
+	return filepath.Join(util.XDGDir("XDG_RUNTIME_DIR", ".local/state"), "daemon.sock")
+}
+
+// run is an invocation of executer requested by `run`.
+type run struct {
+	status Status
+	cancel context.CancelFunc
+}
+
+// Request is an invocation of executer requested by a client.
+type Request struct {
+	Args    []string //command-line arguments without the program name
+	Dir     string   //absolute path of the directory where the relative paths in `Args` are resolved and the commands run
+	Stdin   []byte
+	Stdout  io.Writer
+	Stderr  io.Writer
+	Context context.Context //done when the run is canceled
+}
+
+// Executor runs `r` in the daemon process and returns the exit status,
+// or an error if it cannot be run at all (e.g. invalid arguments).
+type Executor func(r Request) (int, error)
+
+// Server runs executer on behalf of the clients connected to its socket.
+// The runs share the process, so e.g. the toolchains found in `PATH` and the cached compiler versions are reused.
+type Server struct {
+	Execute     Executor
+	IsDebugMode bool
+
+	mutex  sync.Mutex
+	nextID int
+	runs   map[int]*run
+	wg     sync.WaitGroup
+}
+
+func NewServer(execute Executor, isDebugMode bool) *Server {
+	return &Server{
+		Execute:     execute,
+		IsDebugMode: isDebugMode,
+		nextID:      1,
+		runs:        make(map[int]*run),
+	}
+}
+
+// conn is a connection from a client. Writes are serialized as runs notify concurrently.
+type conn struct {
+	c     net.Conn
+	mutex sync.Mutex
+}
+
+func (c *conn) send(message any) error {
+	var b, err = json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, err = c.c.Write(append(b, '\n'))
+	return err
+}
+
+func (c *conn) notify(method string, params any) {
+	c.send(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// chunkWriter notifies what's written as `output`.
+type chunkWriter struct {
+	c      *conn
+	id     int
+	stream string
+}
+
+func (w chunkWriter) Write(p []byte) (int, error) {
+	w.c.notify("output", Chunk{ID: w.id, Stream: w.stream, Data: protocol.NewData(p)})
+	return len(p), nil
+}
+
+// Serve accepts connections on `listener` until `ctx` is done, and then cancels the runs in progress.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		var c, err = listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		go s.handle(&conn{c: c})
+	}
+
+	s.mutex.Lock()
+	for _, r := range s.runs {
+		if r.cancel != nil {
+			r.cancel()
+		}
+	}
+	s.mutex.Unlock()
+	s.wg.Wait()
+	return nil
+
+}
+
+func (s *Server) handle(c *conn) {
+
+	defer c.c.Close()
+
+	var scanner = bufio.NewScanner(c.c)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			c.send(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, err.Error()}})
+			continue
+		}
+		util.DebugPrint(fmt.Sprintf("daemon: %v", line), s.IsDebugMode)
+
+		var result, e = s.dispatch(c, req)
+		if req.ID == nil {
+			continue
+		}
+		if e != nil {
+			c.send(response{JSONRPC: "2.0", ID: req.ID, Error: e})
+		} else {
+			c.send(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+		}
+
+	}
+
+}
+
+func (s *Server) dispatch(c *conn, req request) (any, *rpcError) {
+
+	if req.JSONRPC != "2.0" {
+		return nil, &rpcError{codeInvalidRequest, "`jsonrpc` must be \"2.0\""}
+	}
+
+	switch req.Method {
+
+	case "run":
+		var p runParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		if !filepath.IsAbs(p.Dir) {
+			return nil, &rpcError{codeInvalidParams, "`cwd` must be an absolute path"}
+		}
+		return map[string]int{"run_id": s.start(c, p)}, nil
+
+	case "cancel":
+		var p idParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		var r, ok = s.runs[p.ID]
+		if !ok {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("no such run: %v", p.ID)}
+		}
+		if r.status.State == StateRunning {
+			r.status.State = StateCanceled
+			r.cancel()
+		}
+		return r.status, nil
+
+	case "status":
+		var p idParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		var r, ok = s.runs[p.ID]
+		if !ok {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("no such run: %v", p.ID)}
+		}
+		return r.status, nil
+
+	case "list":
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		var ret = make([]Status, 0, len(s.runs))
+		for _, r := range s.runs {
+			ret = append(ret, r.status)
+		}
+		sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+		return ret, nil
+
+	}
+
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("unknown method: %v", req.Method)}
+
+}
+
+// start begins a run in the background, which notifies its output and then `finished` to `c`.
+func (s *Server) start(c *conn, p runParams) int {
+
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	s.mutex.Lock()
+	var id = s.nextID
+	s.nextID++
+	var r = &run{
+		status: Status{ID: id, Args: p.Args, Dir: p.Dir, State: StateRunning, Start: time.Now()},
+		cancel: cancel,
+	}
+	s.runs[id] = r
+	s.prune()
+	s.mutex.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+
+		var exitStatus, err = s.Execute(Request{
+			Args:    p.Args,
+			Dir:     p.Dir,
+			Stdin:   []byte(p.Stdin),
+			Stdout:  chunkWriter{c, id, "stdout"},
+			Stderr:  chunkWriter{c, id, "stderr"},
+			Context: ctx,
+		})
+
+		s.mutex.Lock()
+		r.status.End = time.Now()
+		r.status.ElapsedSeconds = r.status.End.Sub(r.status.Start).Seconds()
+		r.status.ExitStatus = exitStatus
+		switch {
+		case ctx.Err() != nil:
+			r.status.State = StateCanceled
+		case err != nil:
+			r.status.State = StateFailed
+			r.status.Error = err.Error()
+		default:
+			r.status.State = StateFinished
+		}
+		var status = r.status
+		s.mutex.Unlock()
+
+		c.notify("finished", status)
+	}()
+
+	return id
+
+}
+
+// prune drops the oldest finished runs beyond `maxFinishedRuns`. `s.mutex` must be locked.
+func (s *Server) prune() {
+	var finished = make([]int, 0)
+	for id, r := range s.runs {
+		if r.status.State != StateRunning {
+			finished = append(finished, id)
+		}
+	}
+	if len(finished) <= maxFinishedRuns {
+		return
+	}
+	sort.Ints(finished)
+	for _, id := range finished[:len(finished)-maxFinishedRuns] {
+		delete(s.runs, id)
+	}
+}
+
+// listen creates the socket, replacing a stale one left by a daemon which didn't exit cleanly.
+// Only the user can connect to it, as the daemon runs whatever is requested.
+func listen(socket string) (net.Listener, error) {
+
+	if c, err := net.Dial("unix", socket); err == nil {
+		c.Close()
+		return nil, fmt.Errorf("another daemon is listening on `%v`", socket)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(socket); err == nil {
+		if !isOwnSocket(info) {
+			return nil, fmt.Errorf("`%v` exists and isn't a socket owned by you", socket)
+		}
+		if err := os.Remove(socket); err != nil {
+			return nil, err
+		}
+	}
+
+	var l, err = listenPrivately(socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+
+}
+
+// Run executes the `daemon` subcommand, which runs the requests by `execute`, and returns the exit status.
+func Run(o option.Options, execute Executor, isDebugMode bool) int {
+
+	var socket = o.Socket
+	if socket == "" {
+		socket = DefaultSocket()
+	}
+	var listener, err = listen(socket)
+	if err != nil {
+		util.Eprintf("Failed to listen: %v\n", err)
+		return 1
+	}
+	defer os.Remove(socket)
+
+	var ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	util.Eprintf("Listening on `%v`.\n", socket)
+	if err := NewServer(execute, isDebugMode).Serve(ctx, listener); err != nil {
+		util.Eprintf("Failed to accept a connection: %v\n", err)
+		return 1
+	}
+	return 0
+
+}
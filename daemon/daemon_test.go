@@ -0,0 +1,206 @@
+package daemon
+
+import "bufio"
+import "bytes"
+import "context"
+import "encoding/json"
+import "errors"
+import "net"
+import "os"
+import "path/filepath"
+import "testing"
+import "time"
+
+import "executer/exec"
+
+// client is a minimal client reading the messages in order.
+type client struct {
+	c       net.Conn
+	scanner *bufio.Scanner
+}
+
+func (c client) send(t *testing.T, message string) {
+	t.Helper()
+	if _, err := c.c.Write([]byte(message + "\n")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c client) receive(t *testing.T) map[string]any {
+	t.Helper()
+	c.c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	if !c.scanner.Scan() {
+		t.Fatal("no message", c.scanner.Err())
+	}
+	var ret map[string]any
+	if err := json.Unmarshal(c.scanner.Bytes(), &ret); err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+// execute stands in for executer, running `sh` with the arguments.
+func execute(r Request) (int, error) {
+	if len(r.Args) == 0 {
+		return 0, errors.New("no arguments")
+	}
+	var result, err = exec.Run(exec.Option{
+		Command:   "sh",
+		Arguments: r.Args,
+		Dir:       r.Dir,
+		Stdin:     bytes.NewReader(r.Stdin),
+		Stdout:    r.Stdout,
+		Stderr:    r.Stderr,
+		Context:   r.Context,
+	})
+	return result.ExitStatus, err
+}
+
+func Test_listen(t *testing.T) {
+
+	var dir = filepath.Join(t.TempDir(), "sub")
+
+	t.Run("directory", func(t *testing.T) {
+		var l, err = listen(filepath.Join(dir, "daemon.sock"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Close()
+		if info, err := os.Stat(dir); (err != nil) || (info.Mode().Perm() != 0700) {
+			t.Fatal(info, err)
+		}
+	})
+
+	t.Run("stale socket", func(t *testing.T) {
+		var socket = filepath.Join(dir, "stale.sock")
+		var l, err = listen(socket)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		l.Close()
+		if l, err = listen(socket); err != nil {
+			t.Fatal(err)
+		}
+		l.Close()
+	})
+
+	t.Run("not a socket", func(t *testing.T) {
+		var file = filepath.Join(dir, "file")
+		if err := os.WriteFile(file, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := listen(file); err == nil {
+			t.Fatal("A file other than a socket shouldn't be replaced.")
+		}
+		if _, err := os.Stat(file); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+}
+
+func Test_Server(t *testing.T) {
+
+	var socket = filepath.Join(t.TempDir(), "daemon.sock")
+	var listener, err = listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var served = make(chan error)
+	var s = NewServer(execute, false)
+	go func() {
+		served <- s.Serve(ctx, listener)
+	}()
+
+	if _, err := listen(socket); err == nil {
+		t.Fatal("The second daemon should fail to listen.")
+	}
+
+	if info, err := os.Stat(socket); (err != nil) || (info.Mode().Perm() != 0600) {
+		t.Fatal(info, err)
+	}
+
+	var conn, err2 = net.Dial("unix", socket)
+	if err2 != nil {
+		t.Fatal(err2)
+	}
+	defer conn.Close()
+	var c = client{conn, bufio.NewScanner(conn)}
+
+	t.Run("run", func(t *testing.T) {
+		c.send(t, `{"jsonrpc": "2.0", "id": 1, "method": "run", "params": {"args": ["-c", "cat; echo err >&2; exit 3"], "cwd": "/", "stdin": "in"}}`)
+		if m := c.receive(t); (m["id"] != 1.0) || (m["result"].(map[string]any)["run_id"] != 1.0) {
+			t.Fatal(m)
+		}
+		var stdout, stderr string
+		for {
+			var m = c.receive(t)
+			var params = m["params"].(map[string]any)
+			if m["method"] == "finished" {
+				if (params["state"] != StateFinished) || (params["exit_status"] != 3.0) {
+					t.Fatal(m)
+				}
+				break
+			}
+			if params["stream"] == "stdout" {
+				stdout += params["data"].(string)
+			} else {
+				stderr += params["data"].(string)
+			}
+		}
+		if (stdout != "in") || (stderr != "err\n") {
+			t.Fatal(stdout, stderr)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		c.send(t, `{"jsonrpc": "2.0", "id": 2, "method": "run", "params": {"args": ["-c", "exec sleep 10"], "cwd": "/"}}`)
+		c.receive(t)
+		c.send(t, `{"jsonrpc": "2.0", "id": 3, "method": "cancel", "params": {"run_id": 2}}`)
+		if m := c.receive(t); m["result"].(map[string]any)["state"] != StateCanceled {
+			t.Fatal(m)
+		}
+		if m := c.receive(t); (m["method"] != "finished") || (m["params"].(map[string]any)["state"] != StateCanceled) {
+			t.Fatal(m)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		c.send(t, `{"jsonrpc": "2.0", "id": 4, "method": "run", "params": {"args": [], "cwd": "/"}}`)
+		c.receive(t)
+		if m := c.receive(t); (m["method"] != "finished") || (m["params"].(map[string]any)["state"] != StateFailed) || (m["params"].(map[string]any)["error"] != "no arguments") {
+			t.Fatal(m)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		c.send(t, `{"jsonrpc": "2.0", "id": 4, "method": "list"}`)
+		if m := c.receive(t); len(m["result"].([]any)) != 3 {
+			t.Fatal(m)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		c.send(t, `{"jsonrpc": "2.0", "id": 5, "method": "unknown"}`)
+		if m := c.receive(t); m["error"].(map[string]any)["code"] != float64(codeMethodNotFound) {
+			t.Fatal(m)
+		}
+		c.send(t, `{"jsonrpc": "2.0", "id": 6, "method": "status", "params": {"run_id": 100}}`)
+		if m := c.receive(t); m["error"].(map[string]any)["code"] != float64(codeInvalidParams) {
+			t.Fatal(m)
+		}
+		c.send(t, `not json`)
+		if m := c.receive(t); m["error"].(map[string]any)["code"] != float64(codeParseError) {
+			t.Fatal(m)
+		}
+	})
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+
+}
@@ -0,0 +1,79 @@
+package daemon
+
+import "encoding/json"
+import "time"
+
+import "executer/protocol"
+
+// The messages are those of JSON-RPC 2.0, each of which is written in a line.
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` //absent for notifications, which get no response
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// runParams are the parameters of `run`.
+type runParams struct {
+	Args  []string `json:"args"` //command-line arguments of executer, e.g. `["main.cpp", "--args", "1"]`
+	Dir   string   `json:"cwd"`
+	Stdin string   `json:"stdin,omitempty"`
+}
+
+// idParams are the parameters of `cancel` and `status`.
+type idParams struct {
+	ID int `json:"run_id"`
+}
+
+// Status is the state of a run, returned by `status` and `list`, and notified by `finished`.
+type Status struct {
+	ID             int       `json:"run_id"`
+	Args           []string  `json:"args"`
+	Dir            string    `json:"cwd"`
+	State          string    `json:"state"`
+	ExitStatus     int       `json:"exit_status"`
+	Error          string    `json:"error,omitempty"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"` //zero while running
+	ElapsedSeconds float64   `json:"elapsed_seconds"`
+}
+
+const (
+	StateRunning  = "running"
+	StateFinished = "finished"
+	StateCanceled = "canceled"
+	StateFailed   = "failed" //couldn't be run to the end
+)
+
+// Chunk is a piece of the output of a run, notified by `output`.
+type Chunk struct {
+	ID     int    `json:"run_id"`
+	Stream string `json:"stream"` //`stdout` or `stderr`
+	protocol.Data
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package daemon
+
+import "net"
+import "os"
+
+// listenPrivately listens on `socket`, whose permissions are restricted by `listen` afterwards.
+func listenPrivately(socket string) (net.Listener, error) {
+	return net.Listen("unix", socket)
+}
+
+// isOwnSocket reports whether `info` is of a socket, as the owner isn't known on this platform.
+func isOwnSocket(info os.FileInfo) bool {
+	return info.Mode()&os.ModeSocket != 0
+}
@@ -0,0 +1,23 @@
+//go:build linux || darwin
+
+package daemon
+
+import "net"
+import "os"
+import "syscall"
+
+import "golang.org/x/sys/unix"
+
+// listenPrivately listens on `socket` created with no permissions for the others,
+// so that no one can connect in the meantime before it's restricted further.
+func listenPrivately(socket string) (net.Listener, error) {
+	var mask = unix.Umask(0077)
+	defer unix.Umask(mask)
+	return net.Listen("unix", socket)
+}
+
+// isOwnSocket reports whether `info` is of a socket owned by the user.
+func isOwnSocket(info os.FileInfo) bool {
+	var stat, ok = info.Sys().(*syscall.Stat_t)
+	return (info.Mode()&os.ModeSocket != 0) && ok && (int(stat.Uid) == os.Getuid())
+}
@@ -0,0 +1,143 @@
+package diagnostic
+
+import "regexp"
+import "strings"
+
+var gccRegexp = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)? (fatal error|error|warning|note): (.*)$`)
+
+// parseGCC parses `main.cpp:3:5: error: ...`.
+func parseGCC(lines []string) []Diagnostic {
+	var ret = make([]Diagnostic, 0)
+	for _, line := range lines {
+		if m := gccRegexp.FindStringSubmatch(line); m != nil {
+			ret = append(ret, Diagnostic{m[1], atoi(m[2]), atoi(m[3]), severity(strings.TrimPrefix(m[4], "fatal ")), m[5]})
+		}
+	}
+	return ret
+}
+
+var (
+	rustHeaderRegexp   = regexp.MustCompile(`^(error|warning)(?:\[\w+\])?: (.*)$`)
+	rustLocationRegexp = regexp.MustCompile(`^\s*--> (.+):(\d+):(\d+)$`)
+)
+
+// parseRust parses the header `error[E0425]: ...` followed by the location ` --> src/main.rs:3:5`.
+// Headers without locations (e.g. `error: aborting due to ...`) are ignored.
+func parseRust(lines []string) []Diagnostic {
+	var ret = make([]Diagnostic, 0)
+	var header []string
+	for _, line := range lines {
+		if m := rustHeaderRegexp.FindStringSubmatch(line); m != nil {
+			header = m
+			continue
+		}
+		if m := rustLocationRegexp.FindStringSubmatch(line); (m != nil) && (header != nil) {
+			ret = append(ret, Diagnostic{m[1], atoi(m[2]), atoi(m[3]), severity(header[1]), header[2]})
+			header = nil
+		}
+	}
+	return ret
+}
+
+var (
+	goRegexp     = regexp.MustCompile(`^(\S+\.go):(\d+):(?:(\d+):)? (.*)$`)
+	goTestRegexp = regexp.MustCompile(`^\s+(\S+_test\.go):(\d+): (.*)$`) //`t.Error()` and the like
+)
+
+// parseGo parses `./main.go:3:5: ...` of the compiler and `    main_test.go:12: ...` of failed tests.
+func parseGo(lines []string) []Diagnostic {
+	var ret = make([]Diagnostic, 0)
+	for _, line := range lines {
+		if m := goRegexp.FindStringSubmatch(line); m != nil {
+			ret = append(ret, Diagnostic{m[1], atoi(m[2]), atoi(m[3]), Error, m[4]})
+		} else if m := goTestRegexp.FindStringSubmatch(line); m != nil {
+			ret = append(ret, Diagnostic{m[1], atoi(m[2]), 0, Error, m[3]})
+		}
+	}
+	return ret
+}
+
+var javacRegexp = regexp.MustCompile(`^(.+\.java):(\d+): (error|warning): (.*)$`)
+
+// parseJavac parses `Main.java:3: error: ...`, whose column is shown by `^` two lines below.
+func parseJavac(lines []string) []Diagnostic {
+	var ret = make([]Diagnostic, 0)
+	for i, line := range lines {
+		if m := javacRegexp.FindStringSubmatch(line); m != nil {
+			var d = Diagnostic{m[1], atoi(m[2]), 0, severity(m[3]), m[4]}
+			if (i+2 < len(lines)) && (strings.TrimSpace(lines[i+2]) == "^") {
+				d.Column = strings.Index(lines[i+2], "^") + 1
+			}
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+var ghcRegexp = regexp.MustCompile(`^(.+\.l?hs):(?:(\d+):(\d+)(?:-\d+)?|\((\d+),(\d+)\)-\(\d+,\d+\)): (error|warning)(.*)$`)
+
+// parseGHC parses `Main.hs:3:5: error: [GHC-88464]`, whose message follows in the indented lines.
+func parseGHC(lines []string) []Diagnostic {
+	var ret = make([]Diagnostic, 0)
+	for i, line := range lines {
+		var m = ghcRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		var d = Diagnostic{m[1], atoi(m[2]), atoi(m[3]), severity(m[6]), ""}
+		if m[2] == "" {
+			d.Line, d.Column = atoi(m[4]), atoi(m[5])
+		}
+		var message = make([]string, 0)
+		if s := strings.TrimSpace(strings.TrimPrefix(m[7], ":")); s != "" {
+			message = append(message, s)
+		}
+		for _, l := range lines[i+1:] {
+			if !strings.HasPrefix(l, " ") || (strings.TrimSpace(l) == "") || strings.Contains(l, "|") {
+				break
+			}
+			message = append(message, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "•")))
+		}
+		d.Message = strings.Join(message, " ")
+		ret = append(ret, d)
+	}
+	return ret
+}
+
+var (
+	tscRegexp       = regexp.MustCompile(`^(.+\.[cm]?tsx?)\((\d+),(\d+)\): (error|warning) (.*)$`)
+	tscPrettyRegexp = regexp.MustCompile(`^(.+\.[cm]?tsx?):(\d+):(\d+) - (error|warning) (.*)$`)
+)
+
+// parseTSC parses `src/a.ts(3,5): error TS2304: ...` and its pretty form `src/a.ts:3:5 - error TS2304: ...`.
+func parseTSC(lines []string) []Diagnostic {
+	var ret = make([]Diagnostic, 0)
+	for _, line := range lines {
+		var m = tscRegexp.FindStringSubmatch(line)
+		if m == nil {
+			m = tscPrettyRegexp.FindStringSubmatch(line)
+		}
+		if m != nil {
+			ret = append(ret, Diagnostic{m[1], atoi(m[2]), atoi(m[3]), severity(m[4]), m[5]})
+		}
+	}
+	return ret
+}
+
+var (
+	dartRegexp         = regexp.MustCompile(`^(.+\.dart):(\d+):(\d+): (Error|Warning|Info|Context): (.*)$`)
+	dartAnalyzerRegexp = regexp.MustCompile(`^\s*(error|warning|info) - (.+\.dart):(\d+):(\d+) - (.*)$`)
+)
+
+// parseDart parses `bin/a.dart:3:5: Error: ...` of the compiler and `error - bin/a.dart:3:5 - ...` of the analyzer.
+func parseDart(lines []string) []Diagnostic {
+	var ret = make([]Diagnostic, 0)
+	for _, line := range lines {
+		if m := dartRegexp.FindStringSubmatch(line); m != nil {
+			ret = append(ret, Diagnostic{m[1], atoi(m[2]), atoi(m[3]), severity(m[4]), m[5]})
+		} else if m := dartAnalyzerRegexp.FindStringSubmatch(line); m != nil {
+			ret = append(ret, Diagnostic{m[2], atoi(m[3]), atoi(m[4]), severity(m[1]), m[5]})
+		}
+	}
+	return ret
+}
@@ -0,0 +1,98 @@
+package diagnostic
+
+import "fmt"
+import "path/filepath"
+import "regexp"
+import "strconv"
+import "strings"
+
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Note    Severity = "note"
+)
+
+// Diagnostic is a message of a compiler (or a test runner) associated with a source location.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"` //`0` if unknown
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+var ansiRegexp = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// StripANSI removes ANSI escape sequences (e.g. colors) from `s`.
+func StripANSI(s string) string {
+	return ansiRegexp.ReplaceAllString(s, "")
+}
+
+type parser func(lines []string) []Diagnostic
+
+// parsers maps the base names of commands to their parsers.
+var parsers = map[string]parser{
+	"gcc":    parseGCC,
+	"g++":    parseGCC,
+	"gcc-13": parseGCC,
+	"g++-13": parseGCC,
+	"cargo":  parseRust,
+	"rustc":  parseRust,
+	"go":     parseGo,
+	"javac":  parseJavac,
+	"gradle": parseJavac,
+	"ghc":    parseGHC,
+	"cabal":  parseGHC,
+	"tsc":    parseTSC,
+	"dart":   parseDart,
+}
+
+// Parse extracts the diagnostics from `output` of `command`.
+// Relative paths are resolved against `dir`.
+func Parse(command string, output string, dir string) []Diagnostic {
+
+	var p, ok = parsers[filepath.Base(command)]
+	if !ok {
+		return nil
+	}
+
+	var ret = p(strings.Split(StripANSI(output), "\n"))
+	for i := range ret {
+		if !filepath.IsAbs(ret[i].File) {
+			ret[i].File = filepath.Join(dir, ret[i].File)
+		}
+	}
+	return ret
+
+}
+
+func atoi(s string) int {
+	var ret, _ = strconv.Atoi(s)
+	return ret
+}
+
+func severity(s string) Severity {
+	switch strings.ToLower(s) {
+	case "warning":
+		return Warning
+	case "note", "info", "context":
+		return Note
+	}
+	return Error
+}
+
+// Quickfix formats `ds` so that Vim's default `errorformat` understands them.
+func Quickfix(ds []Diagnostic) string {
+	var ret = ""
+	for _, d := range ds {
+		var message = strings.SplitN(d.Message, "\n", 2)[0]
+		if d.Column == 0 {
+			ret += fmt.Sprintf("%v:%v: %v: %v\n", d.File, d.Line, d.Severity, message)
+		} else {
+			ret += fmt.Sprintf("%v:%v:%v: %v: %v\n", d.File, d.Line, d.Column, d.Severity, message)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,72 @@
+package diagnostic
+
+import "testing"
+import "fmt"
+
+func Test_Parse(t *testing.T) {
+
+	var tests = []struct {
+		command  string
+		output   string
+		expected []Diagnostic
+	}{
+		{
+			"g++",
+			"\x1b[01m\x1b[Kmain.cpp:\x1b[m\x1b[K In function 'int main()':\nmain.cpp:3:11: error: 'y' was not declared in this scope\nmain.cpp:5: warning: unused\n",
+			[]Diagnostic{{"/w/main.cpp", 3, 11, Error, "'y' was not declared in this scope"}, {"/w/main.cpp", 5, 0, Warning, "unused"}},
+		},
+		{
+			"cargo",
+			"warning: unused variable: `x`\n --> src/main.rs:2:9\n  |\nerror[E0308]: mismatched types\n --> src/main.rs:1:25\nerror: aborting due to 1 previous error\n",
+			[]Diagnostic{{"/w/src/main.rs", 2, 9, Warning, "unused variable: `x`"}, {"/w/src/main.rs", 1, 25, Error, "mismatched types"}},
+		},
+		{
+			"go",
+			"# command-line-arguments\n./main.go:2:15: declared and not used: x\n--- FAIL: TestA (0.00s)\n    a_test.go:12: got 1\n",
+			[]Diagnostic{{"/w/main.go", 2, 15, Error, "declared and not used: x"}, {"/w/a_test.go", 12, 0, Error, "got 1"}},
+		},
+		{
+			"javac",
+			"Main.java:3: error: cannot find symbol\n        int x = y;\n                ^\n",
+			[]Diagnostic{{"/w/Main.java", 3, 17, Error, "cannot find symbol"}},
+		},
+		{
+			"ghc",
+			"Main.hs:3:8: error: [GHC-88464]\n    Variable not in scope: y :: Int\n  |\n3 | main = y\n\n/abs/Lib.hs:(4,1)-(5,2): warning: [-Wincomplete-patterns]\n    • Pattern match(es) are non-exhaustive\n",
+			[]Diagnostic{{"/w/Main.hs", 3, 8, Error, "[GHC-88464] Variable not in scope: y :: Int"}, {"/abs/Lib.hs", 4, 1, Warning, "[-Wincomplete-patterns] Pattern match(es) are non-exhaustive"}},
+		},
+		{
+			"tsc",
+			"src/a.ts(3,5): error TS2304: Cannot find name 'y'.\nsrc/b.ts:1:1 - error TS1005: ';' expected.\n",
+			[]Diagnostic{{"/w/src/a.ts", 3, 5, Error, "TS2304: Cannot find name 'y'."}, {"/w/src/b.ts", 1, 1, Error, "TS1005: ';' expected."}},
+		},
+		{
+			"dart",
+			"bin/a.dart:3:5: Error: Undefined name 'y'.\n  error - lib/b.dart:1:2 - Missing semicolon. - expected_token\n",
+			[]Diagnostic{{"/w/bin/a.dart", 3, 5, Error, "Undefined name 'y'."}, {"/w/lib/b.dart", 1, 2, Error, "Missing semicolon. - expected_token"}},
+		},
+		{
+			"python3",
+			"main.py:1:1: error: ...",
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		var ret = Parse(test.command, test.output, "/w")
+		if fmt.Sprint(ret) != fmt.Sprint(test.expected) {
+			t.Fatalf("%v\nexpected: %v\nactual:   %v", test.command, test.expected, ret)
+		}
+	}
+
+}
+
+func Test_Quickfix(t *testing.T) {
+
+	var ret = Quickfix([]Diagnostic{{"/w/a.go", 1, 2, Error, "x\ny"}, {"/w/b.go", 3, 0, Warning, "z"}})
+
+	if ret != "/w/a.go:1:2: error: x\n/w/b.go:3: warning: z\n" {
+		t.Fatal(ret)
+	}
+
+}
@@ -0,0 +1,306 @@
+package diagnostic
+
+import "io/fs"
+import "path/filepath"
+import "regexp"
+import "runtime"
+import "strings"
+
+// libraryPathParts are the parts of paths which indicate that the files aren't of the user.
+var libraryPathParts = []string{
+	"/node_modules/",
+	"/site-packages/",
+	"/dist-packages/",
+	"/.cargo/registry/",
+	"/rustc/",
+	"/lib/python",
+}
+
+// isUserFile reports whether `file` is a source of the user, i.e. under `root` and not of libraries.
+func isUserFile(file string, root string) bool {
+	if !strings.HasPrefix(file, root+"/") {
+		return false
+	}
+	if goroot := runtime.GOROOT(); (goroot != "") && strings.HasPrefix(file, goroot+"/") {
+		return false
+	}
+	for _, part := range libraryPathParts {
+		if strings.Contains(file, part) {
+			return false
+		}
+	}
+	return true
+}
+
+// frame is a location in a stack trace.
+type frame struct {
+	file   string
+	line   int
+	column int
+}
+
+// trace is a runtime error with its frames, innermost first.
+type trace struct {
+	message string
+	frames  []frame
+}
+
+type traceParser func(lines []string) *trace
+
+var traceParsers = []traceParser{
+	parsePythonTrace,
+	parseGoTrace,
+	parseRustTrace,
+	parseJavaTrace,
+	parseNodeTrace,
+	parseGHCTrace,
+}
+
+// ParseRuntime extracts the location of a runtime error from `output` (usually stderr of a program).
+// The innermost frame in the user's sources under `root` is returned. Relative paths are resolved against `root`.
+func ParseRuntime(output string, root string) []Diagnostic {
+
+	var lines = strings.Split(StripANSI(output), "\n")
+
+	for _, p := range traceParsers {
+		var t = p(lines)
+		if t == nil {
+			continue
+		}
+		resolveJavaFiles(root, t)
+		for _, f := range t.frames {
+			var file = f.file
+			if file == "" {
+				continue
+			}
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(root, file)
+			}
+			if isUserFile(file, root) {
+				return []Diagnostic{{file, f.line, f.column, Error, t.message}}
+			}
+		}
+	}
+
+	return nil
+
+}
+
+var (
+	pythonFrameRegexp     = regexp.MustCompile(`^\s*File "(.+)", line (\d+)`)
+	pythonExceptionRegexp = regexp.MustCompile(`^[\w.]+(Error|Exception|Interrupt|Exit|Warning)\b.*$|^[\w.]+: .*$`)
+)
+
+// parsePythonTrace parses `Traceback (most recent call last):`, whose frames are listed outermost first.
+func parsePythonTrace(lines []string) *trace {
+	var start = -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "Traceback (most recent call last):") {
+			start = i
+		}
+	}
+	if start == -1 {
+		return nil
+	}
+	var ret = &trace{}
+	for _, line := range lines[start+1:] {
+		if m := pythonFrameRegexp.FindStringSubmatch(line); m != nil {
+			ret.frames = append([]frame{{m[1], atoi(m[2]), 0}}, ret.frames...)
+		} else if !strings.HasPrefix(line, " ") && pythonExceptionRegexp.MatchString(line) {
+			ret.message = line
+		}
+	}
+	return ret
+}
+
+var goFrameRegexp = regexp.MustCompile(`^\t(.+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+
+// parseGoTrace parses `panic: ...` followed by the goroutine dumps, whose frames are listed innermost first.
+func parseGoTrace(lines []string) *trace {
+	var start = -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ") {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return nil
+	}
+	var ret = &trace{message: lines[start]}
+	var isInFirstGoroutine = false
+	for _, line := range lines[start+1:] {
+		if strings.HasPrefix(line, "goroutine ") {
+			if isInFirstGoroutine {
+				break
+			}
+			isInFirstGoroutine = true
+			continue
+		}
+		if m := goFrameRegexp.FindStringSubmatch(line); m != nil {
+			ret.frames = append(ret.frames, frame{m[1], atoi(m[2]), 0})
+		}
+	}
+	return ret
+}
+
+var (
+	rustPanicRegexp    = regexp.MustCompile(`^thread '.*' panicked at (.+):(\d+):(\d+):$`)
+	rustOldPanicRegexp = regexp.MustCompile(`^thread '.*' panicked at '(.*)', (.+):(\d+):(\d+)$`)
+)
+
+// parseRustTrace parses `thread 'main' panicked at src/main.rs:12:5:` followed by the message.
+func parseRustTrace(lines []string) *trace {
+	for i, line := range lines {
+		if m := rustPanicRegexp.FindStringSubmatch(line); m != nil {
+			var message = ""
+			if i+1 < len(lines) {
+				message = lines[i+1]
+			}
+			return &trace{message, []frame{{m[1], atoi(m[2]), atoi(m[3])}}}
+		}
+		if m := rustOldPanicRegexp.FindStringSubmatch(line); m != nil {
+			return &trace{m[1], []frame{{m[2], atoi(m[3]), atoi(m[4])}}}
+		}
+	}
+	return nil
+}
+
+var (
+	javaExceptionRegexp = regexp.MustCompile(`^(?:Exception in thread ".*" )?([\w.$]+(?:Exception|Error)(?::.*)?)$`)
+	javaFrameRegexp     = regexp.MustCompile(`^\s+at (?:[\w.$/@]+/)?([\w.$]+)\.[\w$<>]+\((\w+\.java):(\d+)\)$`)
+)
+
+// parseJavaTrace parses `Exception in thread "main" ...` followed by `at pkg.Main.f(Main.java:12)`, innermost first.
+// As only the base names of the files are shown, the files are searched by their packages.
+func parseJavaTrace(lines []string) *trace {
+	for i, line := range lines {
+		var m = javaExceptionRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		var ret = &trace{message: m[1]}
+		for _, l := range lines[i+1:] {
+			var f = javaFrameRegexp.FindStringSubmatch(l)
+			if f == nil {
+				if strings.HasPrefix(strings.TrimSpace(l), "at ") || strings.HasPrefix(strings.TrimSpace(l), "...") {
+					continue
+				}
+				break
+			}
+			//`pkg.sub.Main$Inner` in `Main.java` lives in `pkg/sub/Main.java`
+			var dir = ""
+			if j := strings.LastIndex(f[1], "."); j != -1 {
+				dir = strings.ReplaceAll(f[1][:j], ".", "/")
+			}
+			ret.frames = append(ret.frames, frame{filepath.Join(dir, f[2]), atoi(f[3]), 0})
+		}
+		return ret
+	}
+	return nil
+}
+
+var (
+	nodeErrorRegexp = regexp.MustCompile(`^(\w*(?:Error|Exception)\b.*)$`)
+	nodeFrameRegexp = regexp.MustCompile(`^\s+at (?:.* \()?(?:file://)?(/[^:()]+):(\d+):(\d+)\)?$`)
+)
+
+// parseNodeTrace parses `Error: ...` followed by `at f (/path/a.js:12:5)`, innermost first.
+func parseNodeTrace(lines []string) *trace {
+	for i, line := range lines {
+		var m = nodeErrorRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		var ret = &trace{message: m[1]}
+		for _, l := range lines[i+1:] {
+			var f = nodeFrameRegexp.FindStringSubmatch(l)
+			if f == nil {
+				if strings.HasPrefix(strings.TrimSpace(l), "at ") {
+					continue
+				}
+				break
+			}
+			ret.frames = append(ret.frames, frame{f[1], atoi(f[2]), atoi(f[3])})
+		}
+		if len(ret.frames) != 0 {
+			return ret
+		}
+	}
+	return nil
+}
+
+var ghcCallRegexp = regexp.MustCompile(`^\s+\w+, called at (.+\.l?hs):(\d+):(\d+) in `)
+
+// parseGHCTrace parses the message of `error` followed by `CallStack (from HasCallStack):`, innermost first.
+func parseGHCTrace(lines []string) *trace {
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "CallStack (from HasCallStack):") {
+			continue
+		}
+		var ret = &trace{}
+		if i > 0 {
+			ret.message = lines[i-1]
+		}
+		for _, l := range lines[i+1:] {
+			var m = ghcCallRegexp.FindStringSubmatch(l)
+			if m == nil {
+				break
+			}
+			ret.frames = append(ret.frames, frame{m[1], atoi(m[2]), atoi(m[3])})
+		}
+		return ret
+	}
+	return nil
+}
+
+// javaLibraryDirs are the packages of the JDK, whose frames are never in the user's sources.
+var javaLibraryDirs = []string{"java/", "javax/", "jdk/", "sun/", "com/sun/"}
+
+func isJavaLibraryFile(file string) bool {
+	for _, dir := range javaLibraryDirs {
+		if strings.HasPrefix(file, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveJavaFiles replaces the package-relative paths of Java frames with the actual paths under `root`.
+// The paths not found (e.g. of the standard library) are cleared.
+// The tree is walked at most once, indexing the Java files by their base names.
+func resolveJavaFiles(root string, t *trace) {
+
+	var index map[string][]string //base name to the paths, in the order of the walk
+	for i, f := range t.frames {
+		if !strings.HasSuffix(f.file, ".java") || filepath.IsAbs(f.file) {
+			continue
+		}
+		t.frames[i].file = ""
+		if isJavaLibraryFile(f.file) {
+			continue
+		}
+		if index == nil {
+			index = make(map[string][]string)
+			filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				if d.IsDir() && (strings.HasPrefix(d.Name(), ".") || (d.Name() == "build") || (d.Name() == "node_modules")) && (path != root) {
+					return filepath.SkipDir
+				}
+				if !d.IsDir() && strings.HasSuffix(path, ".java") {
+					index[d.Name()] = append(index[d.Name()], path)
+				}
+				return nil
+			})
+		}
+		for _, path := range index[filepath.Base(f.file)] {
+			if strings.HasSuffix(path, "/"+f.file) {
+				t.frames[i].file = path
+				break
+			}
+		}
+	}
+
+}
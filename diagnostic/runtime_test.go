@@ -0,0 +1,85 @@
+package diagnostic
+
+import "testing"
+import "fmt"
+import "os"
+import "path/filepath"
+
+func Test_ParseRuntime(t *testing.T) {
+
+	var tests = []struct {
+		name     string
+		output   string
+		expected []Diagnostic
+	}{
+		{
+			"python",
+			"Traceback (most recent call last):\n  File \"/w/main.py\", line 5, in <module>\n    f()\n  File \"/w/main.py\", line 2, in f\n    x = 1 / 0\n  File \"/usr/lib/python3.11/site-packages/a.py\", line 1, in g\nZeroDivisionError: division by zero\n",
+			[]Diagnostic{{"/w/main.py", 2, 0, Error, "ZeroDivisionError: division by zero"}},
+		},
+		{
+			"go",
+			"panic: runtime error: index out of range [3] with length 3\n\ngoroutine 1 [running]:\nmain.f(...)\n\t/w/main.go:12\nmain.main()\n\t/w/main.go:20 +0x1d\nexit status 2\n",
+			[]Diagnostic{{"/w/main.go", 12, 0, Error, "panic: runtime error: index out of range [3] with length 3"}},
+		},
+		{
+			"rust",
+			"thread 'main' panicked at src/main.rs:12:5:\nindex out of bounds\nnote: run with `RUST_BACKTRACE=1`\n",
+			[]Diagnostic{{"/w/src/main.rs", 12, 5, Error, "index out of bounds"}},
+		},
+		{
+			"rust (old)",
+			"thread 'main' panicked at 'explicit panic', src/main.rs:3:5\n",
+			[]Diagnostic{{"/w/src/main.rs", 3, 5, Error, "explicit panic"}},
+		},
+		{
+			"node",
+			"/w/a.js:3\n    throw new Error('x');\n    ^\n\nError: x\n    at f (/w/node_modules/lib/index.js:1:1)\n    at g (/w/a.js:3:11)\n    at Object.<anonymous> (/w/a.js:5:1)\n    at node:internal/main:1:1\n",
+			[]Diagnostic{{"/w/a.js", 3, 11, Error, "Error: x"}},
+		},
+		{
+			"ghc",
+			"main.out: boom\nCallStack (from HasCallStack):\n  error, called at Main.hs:5:9 in main:Main\n",
+			[]Diagnostic{{"/w/Main.hs", 5, 9, Error, "main.out: boom"}},
+		},
+		{
+			"outside of the root",
+			"panic: x\n\ngoroutine 1 [running]:\nmain.main()\n\t/elsewhere/main.go:20 +0x1d\n",
+			nil,
+		},
+		{
+			"no error",
+			"hello\n",
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		var ret = ParseRuntime(test.output, "/w")
+		if fmt.Sprint(ret) != fmt.Sprint(test.expected) {
+			t.Fatalf("%v\nexpected: %v\nactual:   %v", test.name, test.expected, ret)
+		}
+	}
+
+}
+
+func Test_ParseRuntime_java(t *testing.T) {
+
+	var root = t.TempDir()
+	var file = filepath.Join(root, "app", "src", "main", "java", "pkg", "Main.java")
+	os.MkdirAll(filepath.Dir(file), 0755)
+	os.WriteFile(file, nil, 0644)
+	//a copy of the JDK source, which isn't the user's
+	var library = filepath.Join(root, "jdk", "src", "java", "lang", "Math.java")
+	os.MkdirAll(filepath.Dir(library), 0755)
+	os.WriteFile(library, nil, 0644)
+
+	var output = "Exception in thread \"main\" java.lang.ArithmeticException: / by zero\n\tat java.base/java.lang.Math.floorDiv(Math.java:1)\n\tat pkg.Main.f(Main.java:7)\n\tat pkg.Main.main(Main.java:3)\n"
+	var ret = ParseRuntime(output, root)
+
+	var expected = []Diagnostic{{file, 7, 0, Error, "java.lang.ArithmeticException: / by zero"}}
+	if fmt.Sprint(ret) != fmt.Sprint(expected) {
+		t.Fatal(ret)
+	}
+
+}
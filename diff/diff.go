@@ -0,0 +1,118 @@
+package diff
+
+import "fmt"
+import "strings"
+
+type Style string
+
+const (
+	Unified    Style = "unified"
+	SideBySide Style = "side-by-side"
+)
+
+var Styles = []Style{Unified, SideBySide}
+
+type Option struct {
+	Style     Style
+	IsColored bool //ANSI escape sequences are used iff this is true
+}
+
+const (
+	contextLines = 3   //number of unchanged lines shown around a difference
+	maxLines     = 40  //number of lines shown at most
+	maxWidth     = 100 //number of characters shown at most per line
+	columnWidth  = 40  //width of each column of the side-by-side style
+)
+
+const (
+	red       = "\u001B[091m"
+	green     = "\u001B[092m"
+	cyan      = "\u001B[096m"
+	reverse   = "\u001B[7m"
+	noReverse = "\u001B[27m"
+	reset     = "\u001B[0m"
+)
+
+// FirstDifference returns the 0-indexed position where `expected` and `actual` first differ.
+// The column is counted in runes.
+func FirstDifference(expected []string, actual []string) (int, int, bool) {
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		if i >= len(expected) || i >= len(actual) {
+			return i, 0, true
+		}
+		if expected[i] == actual[i] {
+			continue
+		}
+		var e, a = []rune(expected[i]), []rune(actual[i])
+		var j = 0
+		for j < len(e) && j < len(a) && e[j] == a[j] {
+			j++
+		}
+		return i, j, true
+	}
+	return 0, 0, false
+}
+
+// clip cuts `line` down to at most `width` runes around `column`, highlighting the rune at `column` if `column` isn't negative.
+func clip(line string, column int, width int, isColored bool) string {
+
+	var r = []rune(line)
+	var begin, end = 0, len(r)
+	if len(r) > width {
+		var c = column
+		if c < 0 {
+			c = 0
+		}
+		begin = c - width/2
+		if begin < 0 {
+			begin = 0
+		}
+		end = begin + width
+		if end > len(r) {
+			end = len(r)
+			begin = end - width
+		}
+	}
+
+	var ret = ""
+	if begin > 0 {
+		ret += "…"
+	}
+	if isColored && (column >= begin) && (column < end) {
+		ret += string(r[begin:column]) + reverse + string(r[column]) + noReverse + string(r[column+1:end])
+	} else {
+		ret += string(r[begin:end])
+	}
+	if end < len(r) {
+		ret += "…"
+	}
+	return ret
+
+}
+
+func paint(s string, color string, isColored bool) string {
+	if !isColored {
+		return s
+	}
+	return color + s + reset
+}
+
+// Format describes how `actual` differs from `expected`.
+// An empty string is returned if they are the same.
+func Format(expected string, actual string, o Option) string {
+
+	var e, a = strings.Split(expected, "\n"), strings.Split(actual, "\n")
+
+	var line, column, ok = FirstDifference(e, a)
+	if !ok {
+		return ""
+	}
+
+	var ret = paint(fmt.Sprintf("First difference at line %v, column %v.", line+1, column+1), cyan, o.IsColored) + "\n"
+
+	if o.Style == SideBySide {
+		return ret + formatSideBySide(e, a, line, column, o.IsColored)
+	}
+	return ret + formatUnified(e, a, line, column, o.IsColored)
+
+}
@@ -0,0 +1,98 @@
+package diff
+
+import "testing"
+import "fmt"
+import "strings"
+
+func Test_FirstDifference(t *testing.T) {
+
+	var tests = []struct {
+		expected string
+		actual   string
+		line     int
+		column   int
+		ok       bool
+	}{
+		{"1\n2\n3", "1\n2\n3", 0, 0, false},
+		{"1\n23\n3", "1\n24\n3", 1, 1, true},
+		{"1\n2", "1\n2\n3", 2, 0, true},
+		{"あいう", "あいえ", 0, 2, true},
+	}
+
+	for _, test := range tests {
+		var line, column, ok = FirstDifference(strings.Split(test.expected, "\n"), strings.Split(test.actual, "\n"))
+		if !((line == test.line) && (column == test.column) && (ok == test.ok)) {
+			t.Fatal(test, line, column, ok)
+		}
+	}
+
+}
+
+func Test_Format(t *testing.T) {
+
+	t.Run("same", func(t *testing.T) {
+		if Format("a\nb", "a\nb", Option{Style: Unified}) != "" {
+			t.FailNow()
+		}
+	})
+
+	t.Run("unified", func(t *testing.T) {
+
+		var ret = Format("1\n2\n3\n4\n5\n6\n7\n8\n9", "1\n2\n3\n4\nx\n6\n7\n8\n9", Option{Style: Unified})
+		fmt.Println(ret)
+
+		var expected = `First difference at line 5, column 1.
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++x
+ 6
+ 7
+ 8
+`
+		if ret != expected {
+			t.FailNow()
+		}
+
+	})
+
+	t.Run("side-by-side", func(t *testing.T) {
+
+		var ret = Format("1\n2\n3", "1\n2\n4\n5", Option{Style: SideBySide})
+		fmt.Println(ret)
+
+		var lines = strings.Split(ret, "\n")
+		if !(strings.HasSuffix(lines[4], "3                                        ! 4") && strings.HasSuffix(lines[5], "> 5")) {
+			t.FailNow()
+		}
+
+	})
+
+	t.Run("truncation", func(t *testing.T) {
+
+		var expected, actual []string
+		for i := 0; i < 1000; i++ {
+			expected = append(expected, fmt.Sprint(i))
+			actual = append(actual, fmt.Sprint(i*2))
+		}
+		var ret = Format(strings.Join(expected, "\n"), strings.Join(actual, "\n"), Option{Style: Unified})
+
+		if !(strings.HasSuffix(ret, "more lines)\n") && (strings.Count(ret, "\n") < 50)) {
+			t.Fatal(ret)
+		}
+
+	})
+
+	t.Run("long line", func(t *testing.T) {
+
+		var ret = clip(strings.Repeat("a", 500)+"b"+strings.Repeat("a", 500), 500, 10, false)
+
+		if ret != "…aaaaabaaaa…" {
+			t.Fatal(ret)
+		}
+
+	})
+
+}
@@ -0,0 +1,84 @@
+package diff
+
+import "fmt"
+import "strings"
+
+// formatSideBySide shows the lines with the same line number next to each other, starting a little before the first difference.
+func formatSideBySide(expected []string, actual []string, line int, column int, isColored bool) string {
+
+	var numLines = len(expected)
+	if len(actual) > numLines {
+		numLines = len(actual)
+	}
+
+	var begin = line - contextLines
+	if begin < 0 {
+		begin = 0
+	}
+	var end = begin + maxLines
+	if end > numLines {
+		end = numLines
+	}
+
+	var pad = func(s string) string {
+		var n = len([]rune(s))
+		if n < columnWidth {
+			s += strings.Repeat(" ", columnWidth-n)
+		}
+		return s
+	}
+
+	var ret = fmt.Sprintf("%6v  %v   %v\n", "", pad("Expected"), "Actual")
+	if begin > 0 {
+		ret += fmt.Sprintf("%6v\n", "...")
+	}
+
+	for i := begin; i < end; i++ {
+
+		var c = -1
+		if i == line {
+			c = column
+		}
+
+		var e, a = "", ""
+		var hasExpected, hasActual = i < len(expected), i < len(actual)
+		if hasExpected {
+			e = expected[i]
+		}
+		if hasActual {
+			a = actual[i]
+		}
+
+		var marker = "|"
+		switch {
+		case !hasActual:
+			marker = "<"
+		case !hasExpected:
+			marker = ">"
+		case e != a:
+			marker = "!"
+		}
+
+		//pads before coloring so that the columns are aligned
+		var left = pad(clip(e, -1, columnWidth, false))
+		var right = clip(a, -1, maxWidth, false)
+		if marker != "|" {
+			left = pad(clip(e, c, columnWidth, false))
+			if isColored {
+				left = strings.Replace(left, clip(e, c, columnWidth, false), clip(e, c, columnWidth, true), 1)
+			}
+			left = paint(left, red, isColored)
+			right = paint(clip(a, c, maxWidth, isColored), green, isColored)
+		}
+
+		ret += fmt.Sprintf("%6v  %v %v %v\n", i+1, left, marker, right)
+
+	}
+
+	if end < numLines {
+		ret += fmt.Sprintf("... (%v more lines)\n", numLines-end)
+	}
+
+	return ret
+
+}
@@ -0,0 +1,160 @@
+package diff
+
+import "fmt"
+
+type operation int
+
+const (
+	equal operation = iota
+	deletion
+	insertion
+)
+
+type edit struct {
+	operation operation
+	expected  int //index in `expected` (for `equal` and `deletion`)
+	actual    int //index in `actual` (for `equal` and `insertion`)
+}
+
+// maxTableSize bounds the size of the LCS table. Larger inputs are compared line by line.
+const maxTableSize = 4000000
+
+// edits computes the shortest edit script from `expected` to `actual`.
+func edits(expected []string, actual []string) []edit {
+
+	var n, m = len(expected), len(actual)
+	var ret = make([]edit, 0)
+
+	if (n+1)*(m+1) > maxTableSize {
+		for i := 0; i < n || i < m; i++ {
+			if i < n && i < m && expected[i] == actual[i] {
+				ret = append(ret, edit{equal, i, i})
+				continue
+			}
+			if i < n {
+				ret = append(ret, edit{deletion, i, 0})
+			}
+			if i < m {
+				ret = append(ret, edit{insertion, 0, i})
+			}
+		}
+		return ret
+	}
+
+	//lcs[i][j] is the length of the LCS of `expected[i:]` and `actual[j:]`
+	var lcs = make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var i, j = 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && expected[i] == actual[j]:
+			ret = append(ret, edit{equal, i, j})
+			i++
+			j++
+		case j == m || (i < n && lcs[i+1][j] >= lcs[i][j+1]):
+			ret = append(ret, edit{deletion, i, 0})
+			i++
+		default:
+			ret = append(ret, edit{insertion, 0, j})
+			j++
+		}
+	}
+
+	return ret
+
+}
+
+func formatUnified(expected []string, actual []string, line int, column int, isColored bool) string {
+
+	var es = edits(expected, actual)
+
+	//marks the edits shown (i.e. changes and their context)
+	var isShown = make([]bool, len(es))
+	for k, e := range es {
+		if e.operation == equal {
+			continue
+		}
+		for l := k - contextLines; l <= k+contextLines; l++ {
+			if l >= 0 && l < len(es) {
+				isShown[l] = true
+			}
+		}
+	}
+
+	var ret = ""
+	var numLines = 0
+	var numOmitted = 0
+	for k, e := range es {
+
+		if !isShown[k] {
+			continue
+		}
+		if numLines >= maxLines {
+			numOmitted++
+			continue
+		}
+
+		if (k == 0) || !isShown[k-1] {
+			var numExpected, numActual = 0, 0
+			for l := k; l < len(es) && isShown[l]; l++ {
+				if es[l].operation != insertion {
+					numExpected++
+				}
+				if es[l].operation != deletion {
+					numActual++
+				}
+			}
+			var expectedStart, actualStart = 0, 0
+			for l := 0; l < k; l++ {
+				if es[l].operation != insertion {
+					expectedStart++
+				}
+				if es[l].operation != deletion {
+					actualStart++
+				}
+			}
+			ret += paint(fmt.Sprintf("@@ -%v,%v +%v,%v @@", expectedStart+1, numExpected, actualStart+1, numActual), cyan, isColored) + "\n"
+			numLines++
+		}
+
+		switch e.operation {
+		case equal:
+			ret += " " + clip(expected[e.expected], -1, maxWidth, false) + "\n"
+		case deletion:
+			var c = -1
+			if e.expected == line {
+				c = column
+			}
+			ret += paint("-", red, isColored) + clip(expected[e.expected], c, maxWidth, isColored) + "\n"
+		case insertion:
+			var c = -1
+			if e.actual == line {
+				c = column
+			}
+			ret += paint("+", green, isColored) + clip(actual[e.actual], c, maxWidth, isColored) + "\n"
+		}
+		numLines++
+
+	}
+
+	if numOmitted != 0 {
+		ret += fmt.Sprintf("... (%v more lines)\n", numOmitted)
+	}
+
+	return ret
+
+}
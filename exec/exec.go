@@ -1,7 +1,9 @@
 package exec
 
-// import "fmt"
+import "context"
 import "errors"
+import "fmt"
+import "io"
 import "os"
 import "os/signal"
 import "os/exec"
@@ -15,47 +17,132 @@ type Option struct {
 	CompileOptions             []string
 	Arguments                  []string
 	ExecOptions                []string
+	Dir                        string          //the current directory if empty
+	Env                        []string        //appended to the environment of the current process
+	Stdin                      io.Reader       //`os.Stdin` if nil
+	Stdout                     io.Writer       //`os.Stdout` if nil
+	Stderr                     io.Writer       //`os.Stderr` if nil
+	MessageWriter              io.Writer       //receives stdout instead of `Stdout` if non-nil, for the messages to parse (e.g. of cargo)
+	Timeout                    time.Duration   //The process is killed after this duration if positive.
+	Context                    context.Context //The process is interrupted when this is done (never if nil).
+	MaxOutput                  OutputLimit     //ignored in compile mode, as a long error log isn't a runaway output
+	Artifact                   string          //file produced by the compilation, which makes it cacheable if non-empty
+	Depfile                    string          //dependency file written by the compilation with `-MMD` (C/C++)
 	ShouldMeasureTime          bool
 	ExitStatusWhenCompileError int
 	IsDebugMode                bool
 }
 
-func Execute(o Option) {
+type Result struct {
+	ExitStatus int
+	Signal     string //name of the signal which killed the process, if any
+	Start      time.Time
+	End        time.Time
+	Elapsed    time.Duration
+	CPUTime    time.Duration //user and system time of the process, which is less affected by other processes than `Elapsed`
+	IsTimedOut bool
+	MaxRSS     int64 //peak memory usage in bytes (`0` if unknown)
 
-	var start = time.Now()
-	var exit = func(exitStatus int) {
-		var elapsedSeconds float64 = float64(time.Now().Sub(start).Milliseconds()) / 1000
-		if !o.IsCompileMode && (o.ShouldMeasureTime || o.IsDebugMode) {
-			util.Eprintf("\nElapsed: %.2f(s)\n", elapsedSeconds)
-		}
-		if exitStatus != 0 {
-			os.Exit(exitStatus)
-		}
+	IsOutputTruncated bool
+	OutputBytes       int64 //bytes printed before the output is truncated
+	DiscardedBytes    int64 //bytes discarded after the output is truncated
+}
+
+// Argv returns the command line.
+func (o Option) Argv() []string {
+	var ret = []string{o.Command}
+	ret = append(ret, o.CompileOptions...)
+	ret = append(ret, o.Arguments...)
+	ret = append(ret, o.ExecOptions...)
+	return ret
+}
+
+// WorkingDir returns the directory where the command runs.
+func (o Option) WorkingDir() string {
+	if o.Dir != "" {
+		return o.Dir
 	}
+	var ret, _ = os.Getwd()
+	return ret
+}
 
-	var exitStatusOnFailure = 1
-	if o.IsCompileMode {
-		exitStatusOnFailure = o.ExitStatusWhenCompileError
+// ErrWriter returns where the messages about the command are written, i.e. `o.Stderr` or `os.Stderr` if nil.
+func (o Option) ErrWriter() io.Writer {
+	if o.Stderr != nil {
+		return o.Stderr
 	}
+	return os.Stderr
+}
+
+var ErrInterrupted = errors.New("interrupted by SIGINT")
+var ErrCanceled = errors.New("canceled")
+
+// interruptGracePeriod is the time given to a process to exit after SIGINT is forwarded before it's killed.
+const interruptGracePeriod = 2 * time.Second
+
+// cancelGracePeriod is the time given to a canceled process to exit after SIGINT before it's killed.
+// It's longer than `interruptGracePeriod` so that a canceled executer (e.g. by `--watch`) can kill its program in time.
+const cancelGracePeriod = interruptGracePeriod + time.Second
+
+// groupExitTimeout is how long `Run` waits for the killed descendants to exit, e.g. to release the port of a server.
+const groupExitTimeout = time.Second
+
+// waitDelay is how long `Run` waits for stdout and stderr to be closed after the process exits.
+// The descendants left behind, which are usually killed with the process group, may keep them open.
+const waitDelay = 2 * time.Second
 
-	var args = make([]string, 0)
-	args = append(args, o.CompileOptions...)
-	args = append(args, o.Arguments...)
-	args = append(args, o.ExecOptions...)
+// Run executes the command and returns its result instead of exiting.
+// A non-nil error is returned only when the command couldn't be run to the end.
+func Run(o Option) (Result, error) {
+
+	var ret = Result{}
+
+	var argv = o.Argv()
 
 	if o.IsDebugMode {
-		var l = []string{o.Command}
-		l = append(l, args...)
-		util.DebugPrint(util.ToStringPretty(l), true)
+		util.DebugPrint(util.ToStringPretty(argv), true)
 	}
 
-	var cmd = exec.Command(o.Command, args...)
+	var cmd = exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = o.Dir
 	cmd.Stdin = os.Stdin
+	if o.Stdin != nil {
+		cmd.Stdin = o.Stdin
+	}
 	cmd.Stdout = os.Stdout
+	if o.Stdout != nil {
+		cmd.Stdout = o.Stdout
+	}
+	if o.MessageWriter != nil {
+		cmd.Stdout = o.MessageWriter
+	}
 	cmd.Stderr = os.Stderr
+	if o.Stderr != nil {
+		cmd.Stderr = o.Stderr
+	}
+	var limiter *outputLimiter
+	if o.MaxOutput.IsEnabled() && !o.IsCompileMode {
+		limiter = &outputLimiter{
+			limit: o.MaxOutput,
+			onExceeded: func() {
+				killGroup(cmd)
+			},
+		}
+		cmd.Stdout = limiter.wrap(cmd.Stdout)
+		cmd.Stderr = limiter.wrap(cmd.Stderr)
+	}
+	if o.Env != nil {
+		cmd.Env = append(os.Environ(), o.Env...)
+	}
+	cmd.WaitDelay = waitDelay
+	if tty := setProcessGroup(cmd); tty != -1 {
+		defer restoreForeground(tty)
+	}
+	ret.Start = time.Now()
 	if err := cmd.Start(); err != nil {
-		util.Eprintf("Failed to execute the command: %v\n", err)
-		exit(exitStatusOnFailure)
+		ret.End = time.Now()
+		ret.Elapsed = ret.End.Sub(ret.Start)
+		return ret, err
 	}
 
 	var done = make(chan error)
@@ -63,31 +150,156 @@ func Execute(o Option) {
 		done <- cmd.Wait()
 	}()
 
+	var timedOut = make(chan struct{})
+	if o.Timeout > 0 {
+		var timer = time.AfterFunc(o.Timeout, func() {
+			close(timedOut)
+			killGroup(cmd)
+		})
+		defer timer.Stop()
+	}
+
 	var signalChannel = make(chan os.Signal, 1)
 	signal.Notify(signalChannel, os.Interrupt)
+	defer signal.Stop(signalChannel)
+
+	var canceled <-chan struct{}
+	if o.Context != nil {
+		canceled = o.Context.Done()
+	}
+
+	//The other signals which would terminate executer are passed to the process, which decides what to do.
+	var forwarded = make(chan os.Signal, 1)
+	if len(forwardedSignals) != 0 {
+		signal.Notify(forwarded, forwardedSignals...)
+		defer signal.Stop(forwarded)
+	}
 
 	var err error
-	select {
-	case err = <-done:
-	case <-signalChannel:
-		util.DebugPrint("\nSIGINT is caught.", o.IsDebugMode)
-		if err := cmd.Process.Signal(os.Interrupt); err != nil {
-			util.Eprintln("Failed to send SIGINT.")
-			exit(exitStatusOnFailure)
+loop:
+	for {
+		select {
+		case err = <-done:
+			break loop
+		case sig := <-forwarded:
+			util.DebugPrint(fmt.Sprintf("\n%v is forwarded.", sig), o.IsDebugMode)
+			signalGroup(cmd, sig)
+		case <-canceled:
+			util.DebugPrint("The command is canceled.", o.IsDebugMode)
+			signalGroup(cmd, os.Interrupt)
+			select {
+			case <-done:
+			case <-time.After(cancelGracePeriod):
+				killGroup(cmd)
+				<-done
+			}
+			killGroup(cmd) //the descendants which survived the process
+			waitForGroup(cmd, groupExitTimeout)
+			ret.End = time.Now()
+			ret.Elapsed = ret.End.Sub(ret.Start)
+			ret.Signal, ret.MaxRSS = resourceUsage(cmd.ProcessState)
+			return ret, ErrCanceled
+		case <-signalChannel:
+			util.DebugPrint("\nSIGINT is caught.", o.IsDebugMode)
+			if err := signalGroup(cmd, os.Interrupt); err != nil {
+				ret.End = time.Now()
+				ret.Elapsed = ret.End.Sub(ret.Start)
+				return ret, errors.New("failed to send SIGINT")
+			}
+			select {
+			case <-done:
+			case <-time.After(interruptGracePeriod):
+				killGroup(cmd)
+				<-done
+			}
+			killGroup(cmd)
+			waitForGroup(cmd, groupExitTimeout)
+			ret.End = time.Now()
+			ret.Elapsed = ret.End.Sub(ret.Start)
+			ret.Signal, ret.MaxRSS = resourceUsage(cmd.ProcessState)
+			return ret, ErrInterrupted
 		}
-		<-done
-		exit(exitStatusOnFailure)
+	}
+
+	ret.End = time.Now()
+	ret.Elapsed = ret.End.Sub(ret.Start)
+	ret.CPUTime = cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()
+	ret.Signal, ret.MaxRSS = resourceUsage(cmd.ProcessState)
+	//The process may have exited by itself just before the timer fires.
+	select {
+	case <-timedOut:
+		ret.IsTimedOut = isKilled(cmd.ProcessState)
+	default:
+	}
+	if limiter != nil {
+		limiter.mutex.Lock()
+		ret.IsOutputTruncated = limiter.hasExceeded
+		ret.OutputBytes = limiter.bytes
+		ret.DiscardedBytes = limiter.discarded
+		limiter.mutex.Unlock()
+	}
+
+	//In the foreground, the process gets SIGINT of Ctrl-C directly instead of executer.
+	if isInterrupted(cmd.ProcessState) {
+		return ret, ErrInterrupted
+	}
+
+	//The output of the descendants left behind is cut off.
+	if errors.Is(err, exec.ErrWaitDelay) {
+		err = nil
 	}
 
 	if err != nil {
 		var e *exec.ExitError
-		if errors.As(err, &e) && !o.IsCompileMode {
-			exit(e.ProcessState.ExitCode())
-		} else {
-			exit(exitStatusOnFailure)
+		if !errors.As(err, &e) {
+			return ret, err
+		}
+		ret.ExitStatus = e.ProcessState.ExitCode()
+	}
+
+	return ret, nil
+
+}
+
+// Execute runs the command and returns the result and the exit status, printing the elapsed time and the errors if any to `o.ErrWriter()`.
+// The exit status is `o.ExitStatusWhenCompileError` when a compilation fails.
+func Execute(o Option) (Result, int) {
+
+	var exitStatusOnFailure = 1
+	if o.IsCompileMode {
+		exitStatusOnFailure = o.ExitStatusWhenCompileError
+	}
+
+	var result, err = Run(o)
+
+	if result.IsOutputTruncated {
+		fmt.Fprintf(
+			o.ErrWriter(),
+			"\n\u001B[093mOutput truncated after %v bytes (%v more bytes discarded).\u001B[0m\n",
+			result.OutputBytes,
+			result.DiscardedBytes,
+		)
+	}
+
+	var elapsedSeconds float64 = float64(result.Elapsed.Milliseconds()) / 1000
+	if !o.IsCompileMode && (o.ShouldMeasureTime || o.IsDebugMode) {
+		fmt.Fprintf(o.ErrWriter(), "\nElapsed: %.2f(s)\n", elapsedSeconds)
+	}
+
+	if err != nil {
+		if !errors.Is(err, ErrInterrupted) && !errors.Is(err, ErrCanceled) {
+			fmt.Fprintf(o.ErrWriter(), "Failed to execute the command: %v\n", err)
+		}
+		return result, exitStatusOnFailure
+	}
+
+	if result.ExitStatus != 0 {
+		if o.IsCompileMode {
+			return result, exitStatusOnFailure
 		}
+		return result, result.ExitStatus
 	}
 
-	exit(0)
+	return result, 0
 
 }
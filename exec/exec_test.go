@@ -0,0 +1,68 @@
+package exec
+
+import "bytes"
+import "context"
+import "errors"
+import "io"
+import "testing"
+import "time"
+
+func Test_Run(t *testing.T) {
+
+	t.Run("canceled", func(t *testing.T) {
+		var ctx, cancel = context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		var result, err = Run(Option{Command: "sleep", Arguments: []string{"10"}, Context: ctx})
+		if !errors.Is(err, ErrCanceled) || (result.Elapsed > 5*time.Second) {
+			t.Fatal(result, err)
+		}
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		var result, err = Run(Option{Command: "sleep", Arguments: []string{"10"}, Timeout: 100 * time.Millisecond})
+		if (err != nil) || !result.IsTimedOut {
+			t.Fatal(result, err)
+		}
+	})
+
+	//`sh` waits for `sleep`, which keeps stdout open, so `sleep` has to be killed as well.
+	t.Run("timed out with a child process", func(t *testing.T) {
+		var stdout bytes.Buffer
+		var result, err = Run(Option{
+			Command:   "sh",
+			Arguments: []string{"-c", "sleep 10; true"},
+			Stdout:    &stdout,
+			Timeout:   100 * time.Millisecond,
+		})
+		if (err != nil) || !result.IsTimedOut || (result.Elapsed > 5*time.Second) {
+			t.Fatal(result, err)
+		}
+	})
+
+	//`yes` is killed with `sh`, or it keeps the pipe of stdout open.
+	t.Run("output limit with a child process", func(t *testing.T) {
+		var result, err = Run(Option{
+			Command:   "sh",
+			Arguments: []string{"-c", "yes; true"},
+			Stdout:    io.Discard,
+			MaxOutput: OutputLimit{Bytes: 1000},
+		})
+		if (err != nil) || !result.IsOutputTruncated || (result.Elapsed > 5*time.Second) {
+			t.Fatal(result, err)
+		}
+	})
+
+	t.Run("output limit in compile mode", func(t *testing.T) {
+		var result, err = Run(Option{
+			IsCompileMode: true,
+			Command:       "seq",
+			Arguments:     []string{"1000"},
+			Stdout:        io.Discard,
+			MaxOutput:     OutputLimit{Bytes: 100},
+		})
+		if (err != nil) || result.IsOutputTruncated {
+			t.Fatal(result, err)
+		}
+	})
+
+}
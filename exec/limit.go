@@ -0,0 +1,116 @@
+package exec
+
+import "bytes"
+import "fmt"
+import "io"
+import "regexp"
+import "strconv"
+import "strings"
+import "sync"
+
+// OutputLimit caps what a command prints to stdout and stderr in total.
+type OutputLimit struct {
+	Bytes     int64 //no limit if zero
+	Lines     int64 //no limit if zero
+	IsDiscard bool  //Just discards the rest of the output instead of killing the command.
+}
+
+var (
+	byteLimitRegexp = regexp.MustCompile(`^(\d+)([kKmMgG]?)[bB]?$`)
+	lineLimitRegexp = regexp.MustCompile(`^(\d+)(?:l|lines?)$`)
+)
+
+// ParseOutputLimit parses `<n>` (bytes), `<n>k`, `<n>m`, `<n>g` or `<n>lines`.
+func ParseOutputLimit(s string) (OutputLimit, error) {
+	var ret = OutputLimit{}
+	if m := lineLimitRegexp.FindStringSubmatch(s); m != nil {
+		ret.Lines, _ = strconv.ParseInt(m[1], 10, 64)
+	} else if m := byteLimitRegexp.FindStringSubmatch(s); m != nil {
+		ret.Bytes, _ = strconv.ParseInt(m[1], 10, 64)
+		switch strings.ToLower(m[2]) {
+		case "k":
+			ret.Bytes <<= 10
+		case "m":
+			ret.Bytes <<= 20
+		case "g":
+			ret.Bytes <<= 30
+		}
+	}
+	if !ret.IsEnabled() {
+		return ret, fmt.Errorf("invalid output limit: [ %v ]", s)
+	}
+	return ret, nil
+}
+
+func (l OutputLimit) IsEnabled() bool {
+	return (l.Bytes > 0) || (l.Lines > 0)
+}
+
+// outputLimiter counts the output written through its writers, and cuts it at the limit.
+type outputLimiter struct {
+	limit       OutputLimit
+	onExceeded  func()
+	mutex       sync.Mutex
+	bytes       int64
+	lines       int64
+	discarded   int64
+	hasExceeded bool
+}
+
+type limitedWriter struct {
+	limiter *outputLimiter
+	w       io.Writer
+}
+
+func (l *outputLimiter) wrap(w io.Writer) io.Writer {
+	return &limitedWriter{l, w}
+}
+
+// Write always reports success so that the command doesn't get blocked or see errors.
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+
+	var l = lw.limiter
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.hasExceeded {
+		l.discarded += int64(len(p))
+		return len(p), nil
+	}
+
+	//the length of the prefix of `p` within the limit
+	var n = len(p)
+	if (l.limit.Bytes > 0) && (l.bytes+int64(n) > l.limit.Bytes) {
+		n = int(l.limit.Bytes - l.bytes)
+	}
+	if (l.limit.Lines > 0) && (l.lines >= l.limit.Lines) {
+		n = 0
+	} else if l.limit.Lines > 0 {
+		var lines = l.lines
+		for i, c := range p[:n] {
+			if c != '\n' {
+				continue
+			}
+			lines++
+			if lines == l.limit.Lines {
+				n = i + 1
+				break
+			}
+		}
+	}
+
+	lw.w.Write(p[:n])
+	l.bytes += int64(n)
+	l.lines += int64(bytes.Count(p[:n], []byte("\n")))
+
+	if n < len(p) {
+		l.hasExceeded = true
+		l.discarded += int64(len(p) - n)
+		if !l.limit.IsDiscard {
+			l.onExceeded()
+		}
+	}
+
+	return len(p), nil
+
+}
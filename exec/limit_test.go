@@ -0,0 +1,67 @@
+package exec
+
+import "testing"
+import "bytes"
+
+func Test_ParseOutputLimit(t *testing.T) {
+
+	var tests = []struct {
+		s        string
+		expected OutputLimit
+		ok       bool
+	}{
+		{"100", OutputLimit{Bytes: 100}, true},
+		{"2k", OutputLimit{Bytes: 2048}, true},
+		{"1MB", OutputLimit{Bytes: 1 << 20}, true},
+		{"50lines", OutputLimit{Lines: 50}, true},
+		{"1line", OutputLimit{Lines: 1}, true},
+		{"0", OutputLimit{}, false},
+		{"ten", OutputLimit{}, false},
+	}
+
+	for _, test := range tests {
+		var ret, err = ParseOutputLimit(test.s)
+		if (ret != test.expected) || ((err == nil) != test.ok) {
+			t.Fatal(test, ret, err)
+		}
+	}
+
+}
+
+func Test_outputLimiter(t *testing.T) {
+
+	t.Run("lines", func(t *testing.T) {
+
+		var isKilled = false
+		var l = &outputLimiter{limit: OutputLimit{Lines: 2}, onExceeded: func() { isKilled = true }}
+		var stdout, stderr bytes.Buffer
+		var w1, w2 = l.wrap(&stdout), l.wrap(&stderr)
+
+		w1.Write([]byte("a\n"))
+		w2.Write([]byte("b\nc\n"))
+		w1.Write([]byte("d\n"))
+
+		if !((stdout.String() == "a\n") && (stderr.String() == "b\n") && isKilled && (l.bytes == 4) && (l.discarded == 4)) {
+			t.Fatal(stdout.String(), stderr.String(), isKilled, l.bytes, l.discarded)
+		}
+
+	})
+
+	t.Run("bytes without killing", func(t *testing.T) {
+
+		var isKilled = false
+		var l = &outputLimiter{limit: OutputLimit{Bytes: 3, IsDiscard: true}, onExceeded: func() { isKilled = true }}
+		var stdout bytes.Buffer
+		var w = l.wrap(&stdout)
+
+		w.Write([]byte("ab"))
+		w.Write([]byte("c"))
+		w.Write([]byte("de"))
+
+		if !((stdout.String() == "abc") && !isKilled && l.hasExceeded && (l.discarded == 2)) {
+			t.Fatal(stdout.String(), isKilled, l.discarded)
+		}
+
+	})
+
+}
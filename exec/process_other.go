@@ -0,0 +1,37 @@
+//go:build !linux && !darwin
+
+package exec
+
+import "os"
+import "os/exec"
+import "time"
+
+// setProcessGroup does nothing as process groups aren't supported on this platform.
+func setProcessGroup(cmd *exec.Cmd) int {
+	return -1
+}
+
+func restoreForeground(tty int) {
+}
+
+// signalGroup sends `sig` only to the process itself.
+func signalGroup(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Signal(sig)
+}
+
+// killGroup kills only the process itself.
+func killGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+func waitForGroup(cmd *exec.Cmd, timeout time.Duration) {
+}
+
+// isKilled can't tell how the process ended on this platform.
+func isKilled(state *os.ProcessState) bool {
+	return true
+}
+
+func isInterrupted(state *os.ProcessState) bool {
+	return false
+}
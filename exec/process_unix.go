@@ -0,0 +1,75 @@
+//go:build linux || darwin
+
+package exec
+
+import "errors"
+import "os"
+import "os/exec"
+import "os/signal"
+import "syscall"
+import "time"
+
+import "golang.org/x/sys/unix"
+
+// setProcessGroup makes `cmd` start in its own process group so that its descendants (e.g. the programs run by `sh`)
+// are signaled and killed together with it.
+// When stdin is the terminal where executer is in the foreground, the group is put in the foreground instead,
+// as a background process reading the terminal is stopped. Its file descriptor is returned then (`-1` otherwise).
+func setProcessGroup(cmd *exec.Cmd) int {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var f, ok = cmd.Stdin.(*os.File)
+	if !ok {
+		return -1
+	}
+	var fd = int(f.Fd())
+	if pgrp, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP); (err != nil) || (pgrp != syscall.Getpgrp()) {
+		return -1
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Foreground: true, Ctty: fd}
+	return fd
+}
+
+// restoreForeground puts the process group of executer back in the foreground of the terminal `tty`.
+func restoreForeground(tty int) {
+	//A background process gets SIGTTOU by changing the foreground unless it's ignored.
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+	unix.IoctlSetPointerInt(tty, unix.TIOCSPGRP, syscall.Getpgrp())
+}
+
+// signalGroup sends `sig` to the process group of `cmd`.
+func signalGroup(cmd *exec.Cmd, sig os.Signal) error {
+	var s, ok = sig.(syscall.Signal)
+	if !ok {
+		return cmd.Process.Signal(sig)
+	}
+	return syscall.Kill(-cmd.Process.Pid, s)
+}
+
+// killGroup kills the process group of `cmd`.
+func killGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// waitForGroup waits for up to `timeout` until no process is left in the group of `cmd` after it's killed.
+func waitForGroup(cmd *exec.Cmd, timeout time.Duration) {
+	var deadline = time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if err := syscall.Kill(-cmd.Process.Pid, 0); errors.Is(err, syscall.ESRCH) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// isKilled reports whether the process was terminated by SIGKILL, rather than exited by itself.
+func isKilled(state *os.ProcessState) bool {
+	var status, ok = state.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && (status.Signal() == syscall.SIGKILL)
+}
+
+// isInterrupted reports whether the process was terminated by SIGINT.
+func isInterrupted(state *os.ProcessState) bool {
+	var status, ok = state.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && (status.Signal() == syscall.SIGINT)
+}
@@ -0,0 +1,17 @@
+package exec
+
+import "os"
+import "syscall"
+
+// resourceUsage returns the name of the signal which killed the process and its peak memory usage in bytes.
+func resourceUsage(state *os.ProcessState) (string, int64) {
+	var signal = ""
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		signal = status.Signal().String()
+	}
+	var maxRSS int64 = 0
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		maxRSS = usage.Maxrss //in bytes on macOS
+	}
+	return signal, maxRSS
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package exec
+
+import "os"
+
+// resourceUsage isn't supported on this platform.
+func resourceUsage(state *os.ProcessState) (string, int64) {
+	return "", 0
+}
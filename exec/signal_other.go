@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package exec
+
+import "os"
+
+// forwardedSignals is empty as sending signals other than SIGKILL isn't supported on this platform.
+var forwardedSignals = []os.Signal{}
@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package exec
+
+import "os"
+import "syscall"
+
+// forwardedSignals are passed to the process being executed, in addition to SIGINT.
+var forwardedSignals = []os.Signal{
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGQUIT,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
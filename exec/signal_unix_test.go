@@ -0,0 +1,67 @@
+//go:build linux || darwin
+
+package exec
+
+import "bytes"
+import "context"
+import "errors"
+import "fmt"
+import "os"
+import "strconv"
+import "strings"
+import "syscall"
+import "testing"
+import "time"
+
+func Test_forwardedSignals(t *testing.T) {
+
+	time.AfterFunc(300*time.Millisecond, func() {
+		syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	})
+
+	var result, err = Run(Option{
+		Command:   "sh",
+		Arguments: []string{"-c", "trap 'exit 7' USR1; sleep 1 & wait"},
+	})
+	if (err != nil) || (result.ExitStatus != 7) {
+		t.Fatal(result, err)
+	}
+
+}
+
+// isAlive reports whether the process `pid` is running (i.e. exists and isn't a zombie).
+func isAlive(pid int) bool {
+	var b, err = os.ReadFile(fmt.Sprintf("/proc/%v/stat", pid))
+	if err != nil {
+		return syscall.Kill(pid, 0) == nil //no procfs
+	}
+	var fields = strings.Fields(string(b[bytes.LastIndexByte(b, ')')+1:]))
+	return (len(fields) != 0) && (fields[0] != "Z")
+}
+
+func Test_canceledGroup(t *testing.T) {
+
+	//Both `sh` and `sleep` ignore SIGINT, so they have to be killed.
+	var ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(300*time.Millisecond, cancel)
+	var stdout bytes.Buffer
+	var _, err = Run(Option{
+		Command:   "sh",
+		Arguments: []string{"-c", "trap '' INT; sleep 30 & echo $!; wait"},
+		Stdout:    &stdout,
+		Context:   ctx,
+	})
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatal(err)
+	}
+
+	var pid, err2 = strconv.Atoi(strings.TrimSpace(stdout.String()))
+	if err2 != nil {
+		t.Fatal(stdout.String())
+	}
+	if isAlive(pid) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Fatal("the child process survived")
+	}
+
+}
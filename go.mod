@@ -1,10 +1,10 @@
 module executer
 
-go 1.18
+go 1.20
 
 require (
 	github.com/mattn/go-isatty v0.0.14
 	golang.org/x/exp v0.0.0-20220613132600-b0d781184e0d
 )
 
-require golang.org/x/sys v0.0.0-20211019181941-9d821ace8654 // indirect
+require golang.org/x/sys v0.0.0-20211019181941-9d821ace8654
@@ -0,0 +1,277 @@
+package history
+
+import "bufio"
+import "bytes"
+import "encoding/json"
+import "errors"
+import "fmt"
+import "os"
+import "path/filepath"
+import "strings"
+import "time"
+
+import "executer/option"
+import "executer/util"
+
+// maxEntries is the number of entries kept when the history file is trimmed.
+const maxEntries = 1000
+
+// trimSize is the size of the history file beyond which it's trimmed to the latest `maxEntries` entries.
+// The file is only appended to below it, so that invocations running at the same time don't lose their entries.
+const trimSize = 4 * 1024 * 1024
+
+// Entry is the record of an invocation of executer.
+type Entry struct {
+	Index           int            `json:"index"` //the number listed by `history`, which is kept when the file is trimmed
+	Time            time.Time      `json:"time"`
+	Dir             string         `json:"cwd"`
+	Options         option.Options `json:"options"`
+	ExitStatus      int            `json:"exit_status"`
+	DurationSeconds float64        `json:"duration_seconds"`
+	Snippet         string         `json:"snippet,omitempty"` //the source read from stdin for `-`
+}
+
+// File returns the path to the history file, where each line is an entry in JSON.
+func File() string {
+	return filepath.Join(util.XDGDir("XDG_STATE_HOME", ".local/state"), "history.jsonl")
+}
+
+// Load reads the entries, oldest first. An empty list is returned if the file doesn't exist.
+func Load() ([]Entry, error) {
+
+	var ret = make([]Entry, 0)
+
+	var b, err = os.ReadFile(File())
+	if errors.Is(err, os.ErrNotExist) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var scanner = bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue //ignores broken lines (e.g. written by an old version)
+		}
+		if e.Index == 0 { //written by an old version, numbered by the position
+			e.Index = 1
+			if len(ret) != 0 {
+				e.Index = ret[len(ret)-1].Index + 1
+			}
+		}
+		ret = append(ret, e)
+	}
+
+	return ret, scanner.Err()
+
+}
+
+// lastIndex returns the index of the last entry in `f`, or zero if it's empty or the last line has no index.
+func lastIndex(f *os.File) (int, error) {
+
+	var info, err = f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	//The file is read backwards until the last line is found, as it may be large.
+	var line []byte
+	for offset := info.Size(); ; {
+		var trimmed = bytes.TrimRight(line, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			line = trimmed[i+1:]
+			break
+		}
+		if offset == 0 {
+			line = trimmed
+			break
+		}
+		var n = int64(4096)
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+		var chunk = make([]byte, n)
+		if _, err := f.ReadAt(chunk, offset); err != nil {
+			return 0, err
+		}
+		line = append(chunk, line...)
+	}
+
+	var e struct {
+		Index int `json:"index"`
+	}
+	if err := json.Unmarshal(line, &e); err != nil {
+		return 0, nil
+	}
+	return e.Index, nil
+
+}
+
+// Append adds `e` to the history file as a line numbered next to the last one,
+// and trims the file if it has grown beyond `trimSize`.
+func Append(e Entry) error {
+
+	if err := os.MkdirAll(filepath.Dir(File()), 0755); err != nil {
+		return err
+	}
+
+	//The file is locked so that the entries appended at the same time get distinct indices.
+	var f *os.File
+	for {
+		var err error
+		if f, err = os.OpenFile(File(), os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644); err != nil {
+			return err
+		}
+		if err := lock(f); err != nil {
+			f.Close()
+			return err
+		}
+		//The file may have been replaced by `trim` while waiting for the lock.
+		var opened, err1 = f.Stat()
+		var current, err2 = os.Stat(File())
+		if (err1 == nil) && (err2 == nil) && os.SameFile(opened, current) {
+			break
+		}
+		f.Close()
+		if err1 != nil {
+			return err1
+		}
+	}
+	defer f.Close()
+
+	var index, err = lastIndex(f)
+	if err != nil {
+		return err
+	}
+	if index == 0 { //The last line may be broken or written by an old version.
+		var entries, err = Load()
+		if err != nil {
+			return err
+		}
+		if len(entries) != 0 {
+			index = entries[len(entries)-1].Index
+		}
+	}
+	e.Index = index + 1
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() > trimSize {
+		return trim()
+	}
+	return nil
+
+}
+
+// trim rewrites the history file with the latest `maxEntries` entries, keeping their indices.
+// It's called with the file locked, and replaces it so that the readers never see it half-written.
+func trim() error {
+
+	var entries, err = Load()
+	if err != nil {
+		return err
+	}
+	if len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		var b, err = json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(b, '\n'))
+	}
+
+	var tmp = File() + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, File())
+
+}
+
+// Get returns the entry numbered `index` as listed by `history`, or the latest one if `index` is zero.
+func Get(index int) (Entry, error) {
+	var entries, err = Load()
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, errors.New("the history is empty")
+	}
+	if index == 0 {
+		return entries[len(entries)-1], nil
+	}
+	for _, e := range entries {
+		if e.Index == index {
+			return e, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("no such entry in the history (possibly trimmed): [ %v ]", index)
+}
+
+// summary describes `e` in a line.
+func summary(e Entry) string {
+	var command = e.Options.Source.Original
+	if e.Options.Subcommand != "" {
+		command = e.Options.Subcommand + " " + command
+	}
+	if len(e.Options.CompileArgs) != 0 {
+		command += " --compile-args " + strings.Join(e.Options.CompileArgs, " ")
+	}
+	if len(e.Options.ExecArgs) != 0 {
+		command += " --args " + strings.Join(e.Options.ExecArgs, " ")
+	}
+	return fmt.Sprintf(
+		"%5v  %v  %3v  %7.2f(s)  %v  (%v)",
+		e.Index,
+		e.Time.Local().Format("2006-01-02 15:04:05"),
+		e.ExitStatus,
+		e.DurationSeconds,
+		command,
+		e.Dir,
+	)
+}
+
+// Run executes the `history` subcommand and returns the exit status.
+// The entries are listed if `o.HistoryIndex` is zero, or the entry is shown in detail otherwise.
+func Run(o option.Options) int {
+
+	if o.HistoryIndex != 0 {
+		var e, err = Get(o.HistoryIndex)
+		if err != nil {
+			util.Eprintf("Failed to read the history: %v\n", err)
+			return 1
+		}
+		var b, _ = json.MarshalIndent(e, "", "  ")
+		fmt.Println(string(b))
+		return 0
+	}
+
+	var entries, err = Load()
+	if err != nil {
+		util.Eprintf("Failed to read the history: %v\n", err)
+		return 1
+	}
+	for _, e := range entries {
+		fmt.Println(summary(e))
+	}
+	return 0
+
+}
@@ -0,0 +1,123 @@
+package history
+
+import "fmt"
+import "os"
+import "path/filepath"
+import "sync"
+import "testing"
+import "time"
+
+import "executer/option"
+import "executer/source"
+
+func Test_history(t *testing.T) {
+
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if _, err := Get(0); err == nil {
+		t.Fatal("The history should be empty.")
+	}
+
+	for _, file := range []string{"a.cpp", "b.py"} {
+		var e = Entry{
+			Time:    time.Now(),
+			Dir:     "/w",
+			Options: option.Options{Source: source.New(file), ExecArgs: []string{"x"}},
+		}
+		if err := Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var entries, err = Load()
+	if (err != nil) || (len(entries) != 2) {
+		t.Fatal(entries, err)
+	}
+
+	if e, err := Get(0); (err != nil) || (e.Options.Source.Original != "b.py") {
+		t.Fatal(e, err)
+	}
+
+	if e, err := Get(1); (err != nil) || (e.Options.Source.Original != "a.cpp") || (e.Options.ExecArgs[0] != "x") {
+		t.Fatal(e, err)
+	}
+
+	if _, err := Get(3); err == nil {
+		t.FailNow()
+	}
+
+	t.Run("snippet", func(t *testing.T) {
+		var e = Entry{Time: time.Now(), Dir: "/w", Options: option.Options{Source: source.New("-")}, Snippet: "print(1)\n"}
+		if err := Append(e); err != nil {
+			t.Fatal(err)
+		}
+		if e, err := Get(0); (err != nil) || (e.Snippet != "print(1)\n") {
+			t.Fatal(e, err)
+		}
+	})
+
+	t.Run("trim", func(t *testing.T) {
+		for i := 0; i < maxEntries; i++ {
+			if err := Append(Entry{Time: time.Now(), Dir: fmt.Sprint(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := trim(); err != nil {
+			t.Fatal(err)
+		}
+		var entries, err = Load()
+		if (err != nil) || (len(entries) != maxEntries) || (entries[0].Dir != "0") || (entries[0].Index != 4) {
+			t.Fatal(len(entries), err)
+		}
+		if e, err := Get(4); (err != nil) || (e.Dir != "0") {
+			t.Fatal(e, err)
+		}
+		if _, err := Get(1); err == nil {
+			t.Fatal("The trimmed entry shouldn't be found.")
+		}
+		if err := Append(Entry{Time: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		if e, err := Get(0); (err != nil) || (e.Index != maxEntries+4) {
+			t.Fatal(e, err)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		t.Setenv("XDG_STATE_HOME", t.TempDir())
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := Append(Entry{Time: time.Now()}); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		var entries, _ = Load()
+		for i, e := range entries {
+			if e.Index != i+1 {
+				t.Fatal(entries)
+			}
+		}
+	})
+
+	t.Run("old version", func(t *testing.T) {
+		t.Setenv("XDG_STATE_HOME", t.TempDir())
+		if err := os.MkdirAll(filepath.Dir(File()), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(File(), []byte("{\"cwd\": \"a\"}\nbroken\n{\"cwd\": \"b\"}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := Append(Entry{Dir: "c"}); err != nil {
+			t.Fatal(err)
+		}
+		if e, err := Get(3); (err != nil) || (e.Dir != "c") {
+			t.Fatal(e, err)
+		}
+	})
+
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package history
+
+import "os"
+
+// lock does nothing as file locks aren't supported on this platform.
+func lock(f *os.File) error {
+	return nil
+}
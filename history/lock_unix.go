@@ -0,0 +1,12 @@
+//go:build linux || darwin
+
+package history
+
+import "os"
+
+import "golang.org/x/sys/unix"
+
+// lock locks `f` exclusively until it's closed.
+func lock(f *os.File) error {
+	return unix.Flock(int(f.Fd()), unix.LOCK_EX)
+}
@@ -0,0 +1,140 @@
+package judge
+
+import "strings"
+
+import "executer/runner"
+
+const (
+	inputMarker  = "@input"
+	outputMarker = "@output"
+)
+
+// comments extracts the bodies of the comments in `lines`.
+// Consecutive line comments are joined into one body.
+func comments(lines []string, syntax runner.CommentSyntax) [][]string {
+
+	var ret = make([][]string, 0)
+
+	var body []string
+	var isInBlock = false
+	var isInLines = false
+
+	var flush = func() {
+		if body != nil {
+			ret = append(ret, body)
+		}
+		body = nil
+		isInBlock = false
+		isInLines = false
+	}
+
+	for _, line := range lines {
+
+		if isInBlock {
+			if i := strings.Index(line, syntax.BlockEnd); i != -1 {
+				if strings.TrimSpace(line[:i]) != "" {
+					body = append(body, line[:i])
+				}
+				flush()
+			} else {
+				body = append(body, line)
+			}
+			continue
+		}
+
+		var l = strings.TrimSpace(line)
+
+		if (syntax.Line != "") && strings.HasPrefix(l, syntax.Line) {
+			if !isInLines {
+				flush()
+				isInLines = true
+				body = make([]string, 0)
+			}
+			l = strings.TrimPrefix(l, syntax.Line)
+			l = strings.TrimPrefix(l, " ")
+			body = append(body, l)
+			continue
+		}
+
+		if isInLines {
+			flush()
+		}
+
+		if syntax.BlockBegin != "" {
+			if i := strings.Index(line, syntax.BlockBegin); i != -1 {
+				var rest = line[i+len(syntax.BlockBegin):]
+				body = make([]string, 0)
+				if j := strings.Index(rest, syntax.BlockEnd); j != -1 {
+					body = append(body, rest[:j])
+					flush()
+					continue
+				}
+				isInBlock = true
+				if strings.TrimSpace(rest) != "" {
+					body = append(body, rest)
+				}
+			}
+		}
+
+	}
+	flush()
+
+	return ret
+
+}
+
+// ParseInline extracts the test cases embedded in the comments of a source.
+//
+//	/* @input
+//	3
+//	@output
+//	6
+//	*/
+//
+// Each `@input` starts a new case, and `@output` is optional.
+func ParseInline(lines []string, syntax runner.CommentSyntax) []Case {
+
+	var ret = make([]Case, 0)
+
+	for _, body := range comments(lines, syntax) {
+
+		var c *Case
+		var isInOutput = false
+
+		var flush = func() {
+			if c != nil {
+				ret = append(ret, *c)
+			}
+			c = nil
+		}
+
+		for _, line := range body {
+			switch strings.TrimSpace(line) {
+			case inputMarker:
+				flush()
+				c = &Case{Input: make([]byte, 0)}
+				isInOutput = false
+			case outputMarker:
+				if c != nil {
+					c.HasExpected = true
+					c.Expected = make([]byte, 0)
+					isInOutput = true
+				}
+			default:
+				if c == nil {
+					continue
+				}
+				if isInOutput {
+					c.Expected = append(c.Expected, line+"\n"...)
+				} else {
+					c.Input = append(c.Input, line+"\n"...)
+				}
+			}
+		}
+		flush()
+
+	}
+
+	return ret
+
+}
@@ -0,0 +1,90 @@
+package judge
+
+import "testing"
+import "strings"
+
+import "executer/runner"
+
+func Test_ParseInline(t *testing.T) {
+
+	t.Run("block comments", func(t *testing.T) {
+
+		var source = `#include <iostream>
+/* @input
+1 2
+@output
+3
+@input
+4 5
+@output
+9
+*/
+int main() {}
+/* an ordinary comment */`
+
+		var syntax, _ = runner.Comment("cpp")
+		var cases = ParseInline(strings.Split(source, "\n"), syntax)
+
+		if len(cases) != 2 {
+			t.Fatal(cases)
+		}
+		if !((string(cases[0].Input) == "1 2\n") && (string(cases[0].Expected) == "3\n") && cases[0].HasExpected) {
+			t.Fatal(cases[0])
+		}
+		if !((string(cases[1].Input) == "4 5\n") && (string(cases[1].Expected) == "9\n") && cases[1].HasExpected) {
+			t.Fatal(cases[1])
+		}
+
+	})
+
+	t.Run("line comments", func(t *testing.T) {
+
+		var source = `# @input
+# 3
+#   1 2 3
+# @output
+# 6
+
+# @input
+# 0
+print(sum(map(int, input().split())))`
+
+		var syntax, _ = runner.Comment("py")
+		var cases = ParseInline(strings.Split(source, "\n"), syntax)
+
+		if len(cases) != 2 {
+			t.Fatal(cases)
+		}
+		if !((string(cases[0].Input) == "3\n  1 2 3\n") && (string(cases[0].Expected) == "6\n")) {
+			t.Fatal(cases[0])
+		}
+		if !((string(cases[1].Input) == "0\n") && !cases[1].HasExpected) {
+			t.Fatal(cases[1])
+		}
+
+	})
+
+	t.Run("no case", func(t *testing.T) {
+
+		var syntax, _ = runner.Comment("go")
+		var cases = ParseInline([]string{"//comment", "package main"}, syntax)
+
+		if len(cases) != 0 {
+			t.Fatal(cases)
+		}
+
+	})
+
+}
+
+func Test_Compare(t *testing.T) {
+
+	if !Compare([]byte("1 2\n3\n"), []byte("1 2  \r\n3\n\n")) {
+		t.FailNow()
+	}
+
+	if Compare([]byte("1 2\n3\n"), []byte("1 2\n4\n")) {
+		t.FailNow()
+	}
+
+}
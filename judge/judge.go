@@ -0,0 +1,259 @@
+package judge
+
+import "bytes"
+import "fmt"
+import "io"
+import "os"
+import "path/filepath"
+import "sort"
+import "strings"
+import "time"
+
+import "executer/diff"
+import "executer/exec"
+import "executer/util"
+
+type Case struct {
+	Name        string
+	Input       []byte
+	Expected    []byte
+	HasExpected bool
+}
+
+type Verdict string
+
+const (
+	Accepted          Verdict = "AC"
+	WrongAnswer       Verdict = "WA"
+	RuntimeError      Verdict = "RE"
+	TimeLimitExceeded Verdict = "TLE"
+	NoExpected        Verdict = "--" //the case has no expected output
+)
+
+var verdictColors = map[Verdict]string{
+	Accepted:          "\u001B[092m",
+	WrongAnswer:       "\u001B[091m",
+	RuntimeError:      "\u001B[093m",
+	TimeLimitExceeded: "\u001B[093m",
+	NoExpected:        "\u001B[094m",
+}
+
+// Normalize ignores trailing whitespaces of each line and trailing empty lines.
+func Normalize(b []byte) string {
+	var lines = strings.Split(string(b), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// Compare reports whether `actual` is accepted as `expected`.
+func Compare(expected []byte, actual []byte) bool {
+	return Normalize(expected) == Normalize(actual)
+}
+
+// PrintMismatch shows how the actual output differs from the expected one to stderr.
+func PrintMismatch(expected []byte, actual []byte, o diff.Option) {
+	util.Eprintf("\n%v", diff.Format(Normalize(expected), Normalize(actual), o))
+}
+
+// RunProgram executes `program` with `input` as stdin and returns what it printed to stdout.
+func RunProgram(program exec.Option, input []byte) ([]byte, exec.Result, error) {
+	var stdout bytes.Buffer
+	program.Stdin = bytes.NewReader(input)
+	program.Stdout = &stdout
+	var result, err = exec.Run(program)
+	return stdout.Bytes(), result, err
+}
+
+// Option configures how `Run` executes the cases.
+type Option struct {
+	Jobs      int           //number of cases executed concurrently
+	TimeLimit time.Duration //no limit if zero
+	Diff      diff.Option   //The verdicts are colored as well iff `Diff.IsColored`.
+	Stdout    io.Writer     //receives the output of the cases without the expected output (`os.Stdout` if nil)
+	Stderr    io.Writer     //receives the report (`os.Stderr` if nil)
+
+	//OnCase is called for each case in order, after it's reported, unless it failed to be executed.
+	OnCase func(name string, verdict Verdict, result exec.Result)
+}
+
+type outcome struct {
+	actual  []byte
+	stderr  []byte //what the program printed to stderr, buffered to keep the report in order
+	result  exec.Result
+	err     error
+	verdict Verdict
+}
+
+func judge(program exec.Option, c Case, o Option) outcome {
+
+	var ret = outcome{}
+
+	var stderr bytes.Buffer
+	program.Stderr = &stderr
+	if o.TimeLimit > 0 {
+		//The process is killed only after a generous margin, as the wall-clock time is inflated by the other cases.
+		program.Timeout = 2*o.TimeLimit + time.Second
+	}
+
+	ret.actual, ret.result, ret.err = RunProgram(program, c.Input)
+	ret.stderr = stderr.Bytes()
+
+	ret.verdict = func() Verdict {
+		if (o.TimeLimit > 0) && (ret.result.IsTimedOut || (ret.result.CPUTime > o.TimeLimit)) {
+			return TimeLimitExceeded
+		}
+		if ret.result.ExitStatus != 0 {
+			return RuntimeError
+		}
+		if !c.HasExpected {
+			return NoExpected
+		}
+		if !Compare(c.Expected, ret.actual) {
+			return WrongAnswer
+		}
+		return Accepted
+	}()
+
+	return ret
+
+}
+
+// Run executes `program` for each case, reports the verdicts to `o.Stderr` and returns the exit status.
+// The cases are executed concurrently when `o.Jobs` is more than one, but they are reported in order.
+func Run(program exec.Option, cases []Case, o Option) int {
+
+	var jobs = o.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	var outcomes = make([]outcome, len(cases))
+	var done = make([]chan struct{}, len(cases))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	var queue = make(chan int)
+	go func() {
+		for i := range cases {
+			queue <- i
+		}
+		close(queue)
+	}()
+	for k := 0; k < jobs; k++ {
+		go func() {
+			for i := range queue {
+				outcomes[i] = judge(program, cases[i], o)
+				close(done[i])
+			}
+		}()
+	}
+
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if o.Stdout != nil {
+		stdout = o.Stdout
+	}
+	if o.Stderr != nil {
+		stderr = o.Stderr
+	}
+	var color = func(code string) string {
+		if o.Diff.IsColored {
+			return code
+		}
+		return ""
+	}
+	var reset = color("\u001B[0m")
+
+	var verdicts = make(map[Verdict]int)
+	var hasError = false
+
+	for i, c := range cases {
+
+		<-done[i]
+		var r = outcomes[i]
+
+		var name = c.Name
+		if name == "" {
+			name = fmt.Sprintf("#%v", i+1)
+		}
+
+		if r.err != nil {
+			fmt.Fprintf(stderr, "Case %v: Failed to execute the command: %v\n", name, r.err)
+			hasError = true
+			continue
+		}
+
+		stderr.Write(r.stderr)
+
+		verdicts[r.verdict]++
+
+		fmt.Fprintf(
+			stderr,
+			"Case %v: %v%v%v (%.2f(s), CPU %.2f(s))\n",
+			name,
+			color(verdictColors[r.verdict]),
+			r.verdict,
+			reset,
+			float64(r.result.Elapsed.Milliseconds())/1000,
+			float64(r.result.CPUTime.Milliseconds())/1000,
+		)
+
+		switch r.verdict {
+		case WrongAnswer:
+			fmt.Fprintf(stderr, "\n%v\n", diff.Format(Normalize(c.Expected), Normalize(r.actual), o.Diff))
+		case RuntimeError:
+			fmt.Fprintf(stderr, "Exited with status %v.\n\n", r.result.ExitStatus)
+		case NoExpected:
+			stdout.Write(r.actual)
+		}
+
+		if o.OnCase != nil {
+			o.OnCase(name, r.verdict, r.result)
+		}
+
+	}
+
+	if hasError {
+		return 1
+	}
+
+	var failed = verdicts[WrongAnswer] + verdicts[RuntimeError] + verdicts[TimeLimitExceeded]
+	if failed != 0 {
+		fmt.Fprintf(stderr, "%v%v/%v cases failed.%v\n", color("\u001B[091m"), failed, len(cases), reset)
+		return 1
+	}
+	fmt.Fprintf(stderr, "%vNo case failed. (%v cases)%v\n", color("\u001B[092m"), len(cases), reset)
+	return 0
+
+}
+
+// LoadDir reads the cases `<dir>/*.in`, whose expected outputs are `<dir>/*.out` if exist.
+func LoadDir(dir string) ([]Case, error) {
+
+	var inputs, err = filepath.Glob(filepath.Join(dir, "*.in"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(inputs)
+
+	var ret = make([]Case, 0, len(inputs))
+	for _, input := range inputs {
+		var c = Case{Name: filepath.Base(input)}
+		if c.Input, err = os.ReadFile(input); err != nil {
+			return nil, err
+		}
+		var output = strings.TrimSuffix(input, ".in") + ".out"
+		if util.IsFile(output) {
+			if c.Expected, err = os.ReadFile(output); err != nil {
+				return nil, err
+			}
+			c.HasExpected = true
+		}
+		ret = append(ret, c)
+	}
+
+	return ret, nil
+
+}
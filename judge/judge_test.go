@@ -0,0 +1,48 @@
+package judge
+
+import "bytes"
+import "strings"
+import "testing"
+
+import "executer/exec"
+
+func Test_Run(t *testing.T) {
+
+	var cases = []Case{
+		{Name: "ac", Input: []byte("1\n"), Expected: []byte("1"), HasExpected: true},
+		{Name: "wa", Input: []byte("2\n"), Expected: []byte("3\n"), HasExpected: true},
+		{Input: []byte("4\n")},
+	}
+
+	var stdout, stderr bytes.Buffer
+	var names = make([]string, 0)
+	var verdicts = make([]Verdict, 0)
+	var o = Option{
+		Jobs:   2,
+		Stdout: &stdout,
+		Stderr: &stderr,
+		OnCase: func(name string, verdict Verdict, result exec.Result) {
+			names = append(names, name)
+			verdicts = append(verdicts, verdict)
+		},
+	}
+
+	var exitStatus = Run(exec.Option{Command: "cat"}, cases, o)
+
+	if exitStatus != 1 {
+		t.Fatal(exitStatus, stderr.String())
+	}
+	if (len(verdicts) != 3) || (verdicts[0] != Accepted) || (verdicts[1] != WrongAnswer) || (verdicts[2] != NoExpected) {
+		t.Fatal(verdicts)
+	}
+	if names[2] != "#3" {
+		t.Fatal(names)
+	}
+	if stdout.String() != "4\n" {
+		t.Fatal(stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Case wa: WA (") || strings.Contains(stderr.String(), "\u001B[") {
+		t.Fatal(stderr.String())
+	}
+
+}
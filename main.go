@@ -1,17 +1,37 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"executer/bundle"
+	"executer/cache"
+	"executer/clean"
+	"executer/config"
+	"executer/daemon"
+	"executer/diagnostic"
+	"executer/diff"
 	"executer/exec"
+	"executer/history"
+	"executer/judge"
 	"executer/option"
+	"executer/protocol"
+	"executer/report"
+	"executer/runner"
+	"executer/snippet"
+	"executer/source"
+	"executer/stress"
 	"executer/util"
+	"executer/watch"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"regexp"
-	"runtime"
 	"strings"
+	"time"
 
 	"github.com/mattn/go-isatty"
+	"golang.org/x/exp/slices"
 )
 
 const (
@@ -21,9 +41,21 @@ const (
 
 var isDebugMode = false
 
+// session is where an invocation reads and writes: the process itself, or a request to the daemon.
+type session struct {
+	Dir       string //where the relative paths are resolved and the commands run
+	Stdin     []byte //given to each command instead of `os.Stdin` if non-nil
+	Stdout    io.Writer
+	Stderr    io.Writer
+	Context   context.Context //The commands are canceled when this is done (never if nil).
+	IsColored bool
+}
+
 func main() {
 
-	if isatty.IsTerminal(os.Stderr.Fd()) {
+	var isColored = isatty.IsTerminal(os.Stderr.Fd())
+
+	if isColored {
 		util.Eprintln("\u001B[1;034m==================================================================\u001B[0m")
 	}
 
@@ -36,418 +68,362 @@ func main() {
 		util.Eprintf("Failed to parse command-line options: %v\n", err)
 		os.Exit(exitStatusWhenCompileError)
 	}
-	util.DebugPrint(option, isDebugMode)
 
-	if option.IsOnlyCompileMode {
-		util.Eprintln("\u001B[094mOnly-compile mode.\u001B[0m")
+	if option.Subcommand == "history" {
+		os.Exit(history.Run(option))
+	}
+
+	if option.Subcommand == "daemon" {
+		os.Exit(daemon.Run(option, executeRequest, isDebugMode))
+	}
+
+	if option.Subcommand == "clean" {
+		os.Exit(clean.Run(option))
 	}
 
-	var createExecOption = func(command string, isCompileMode bool) exec.Option {
-		return exec.Option{
-			IsCompileMode:              isCompileMode,
-			Command:                    command,
-			CompileOptions:             option.CompileArgs,
-			Arguments:                  []string{option.Source.Path},
-			ExecOptions:                option.ExecArgs,
-			ShouldMeasureTime:          option.ShouldMeasureTime,
+	//the source of the snippet to rerun
+	var snippetSource = ""
+	if option.Subcommand == "rerun" {
+		var e, err = history.Get(option.HistoryIndex)
+		if err != nil {
+			util.Eprintf("Failed to read the history: %v\n", err)
+			os.Exit(exitStatusWhenCompileError)
+		}
+		if err := os.Chdir(e.Dir); err != nil {
+			util.Eprintf("Failed to change the directory: %v\n", err)
+			os.Exit(exitStatusWhenCompileError)
+		}
+		option = e.Options
+		snippetSource = e.Snippet
+	}
+
+	//The invocations by the watcher are recorded instead.
+	if option.ShouldWatch {
+		util.DebugPrint(option, isDebugMode)
+		var base = exec.Option{
 			ExitStatusWhenCompileError: exitStatusWhenCompileError,
 			IsDebugMode:                isDebugMode,
 		}
+		os.Exit(watch.Run(option, os.Args, base, isColored))
 	}
 
-	//yrun.sh
-	//We respect `yrun.sh` iff the following three conditions are met.
-	//1. It exists.
-	//2. It isn't empty.
-	//3. It doesn't consist only of comments.
-	{
-		var file = "./yrun.sh"
-		if util.IsFile(file) {
+	var cwd, _ = os.Getwd()
+	var s = session{Dir: cwd, Stdout: os.Stdout, Stderr: os.Stderr, IsColored: isColored}
+	if (option.Source.Original == "-") && (snippetSource != "") {
+		s.Stdin = []byte(snippetSource) //replays the snippet recorded by the entry
+	}
+	os.Exit(run(option, s))
 
-			//checks if `./yrun.sh` is empty
-			var lines = util.ReadFileUnchecked(file)
-			var isYrunShEmpty = true
-			for _, line := range lines {
-				var l = strings.TrimSpace(line)
-				if !((l == "") || strings.HasPrefix(l, "#")) {
-					isYrunShEmpty = false
-					break
-				}
-			}
+}
 
-			if !isYrunShEmpty {
-				var o = createExecOption("bash", false)
-				o.CompileOptions = nil
-				o.Arguments = append([]string{file}, o.Arguments...)
-				exec.Execute(o)
-				os.Exit(0)
-			}
+// executeRequest runs a request to the daemon in this process.
+func executeRequest(r daemon.Request) (int, error) {
 
-		}
+	if len(r.Args) == 0 {
+		return 0, errors.New("no arguments specified")
+	}
+	if slices.Contains(r.Args, "-h") || slices.Contains(r.Args, "--help") {
+		return 0, errors.New("`--help` is not supported by the daemon")
 	}
 
-	var s = option.Source
+	var o, err = option.ParseIn(append([]string{os.Args[0]}, r.Args...), r.Dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse command-line options: %w", err)
+	}
+	if (o.Subcommand != "") || o.ShouldWatch {
+		return 0, errors.New("subcommands and `--watch` are not supported by the daemon")
+	}
 
-	switch s.Ext {
+	return run(o, session{
+		Dir:     r.Dir,
+		Stdin:   r.Stdin,
+		Stdout:  r.Stdout,
+		Stderr:  r.Stderr,
+		Context: r.Context,
+	}), nil
 
-	case "py":
-		{
-			var o exec.Option
-			if runtime.GOOS == "darwin" {
-				o = createExecOption("python3.11", false)
-			} else {
-				o = createExecOption("python3", false)
-			}
-			exec.Execute(o)
-			os.Exit(0)
-		}
+}
 
-	case "rb":
-		{
-			var o exec.Option = createExecOption("ruby", false)
-			exec.Execute(o)
-			os.Exit(0)
-		}
+// run compiles and runs the source as specified by `option` in `s`, and returns the exit status.
+func run(option option.Options, s session) int {
 
-	case "sh":
-		{
-			var o = createExecOption("bash", false)
-			exec.Execute(o)
-			os.Exit(0)
-		}
+	var isColored = s.IsColored
 
-	case "gp":
-		{
-			var o = createExecOption("gnuplot", false)
-			o.CompileOptions = append([]string{"--persist"}, o.CompileOptions...)
-			exec.Execute(o)
-			os.Exit(0)
-		}
+	//In `jsonl`, the output is written as events to stdout.
+	var emitter *protocol.Emitter
+	if option.Protocol == protocol.FormatJSONL {
+		emitter = protocol.NewEmitter(s.Stdout)
+		isColored = false
+	}
 
-	case "sql":
-		{
-			var o = createExecOption("sqlite3", false)
-			o.CompileOptions = append(
-				append(
-					[]string{":memory:", "-init", "", "-batch"},
-					o.CompileOptions...,
-				),
-				fmt.Sprintf(".read %v", s.Path),
-			)
-			o.Arguments = nil
-			exec.Execute(o)
-			os.Exit(0)
-		}
+	util.DebugPrint(option, isDebugMode)
 
-	case "bats": //testing framework for Bash
-		{
-			var o = createExecOption("bats", false)
-			o.CompileOptions = append([]string{"--print-output-on-failure", "--show-output-of-passing-tests"}, o.CompileOptions...)
-			exec.Execute(o)
-			os.Exit(0)
+	//finish records the invocation to the history, and returns the exit status.
+	//The options are recorded as specified, i.e. before the profile is applied.
+	var recorded = option
+	var dir = s.Dir
+	var snippetSource = ""
+	var start = time.Now()
+	var cleanup = func() {}
+	var finish = func(exitStatus int) int {
+		cleanup()
+		var e = history.Entry{
+			Time:            start,
+			Dir:             dir,
+			Options:         recorded,
+			ExitStatus:      exitStatus,
+			DurationSeconds: time.Now().Sub(start).Seconds(),
+			Snippet:         snippetSource,
 		}
-
-	case "awk":
-		{
-			var prog = strings.Join(util.ReadFileUnchecked(s.Path), "\n")
-			//We require there is a `BEGIN` block to avoid stdin's begin read.
-			if !strings.Contains(prog, "BEGIN {") {
-				util.Eprintln("The input doesn't include `BEGIN { ... }` block.")
-				os.Exit(exitStatusWhenCompileError)
-			}
-			var o = createExecOption("awk", false)
-			o.Arguments = []string{prog}
-			exec.Execute(o)
-			os.Exit(0)
+		if err := history.Append(e); err != nil {
+			util.DebugPrint(fmt.Sprintf("Failed to record the history: %v", err), isDebugMode)
 		}
+		if emitter != nil {
+			emitter.Exit(exitStatus)
+		}
+		return exitStatus
+	}
 
-	case "js":
-		{
-			var o = createExecOption("node", false)
-			exec.Execute(o)
-			os.Exit(0)
+	//snippet
+	//The source read from stdin is saved as a file, and then processed as usual.
+	if option.Source.Original == "-" {
+		var stdin io.Reader = os.Stdin
+		if s.Stdin != nil {
+			stdin = bytes.NewReader(s.Stdin)
+			s.Stdin = []byte{} //consumed as the source
+		}
+		var b, err = io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(s.Stderr, "Failed to read the source from stdin: %v\n", err)
+			return finish(exitStatusWhenCompileError)
+		}
+		snippetSource = string(b) //recorded to be replayed by `rerun`
+		root, path, f, err := snippet.Materialize(bytes.NewReader(b), option.Lang)
+		if err != nil {
+			fmt.Fprintf(s.Stderr, "Failed to save the source read from stdin: %v\n", err)
+			return finish(exitStatusWhenCompileError)
 		}
+		cleanup = f
+		option.Source = source.New(path)
+		s.Dir = root //runs in the snippet directory, not affected by the project around
+	}
 
-	case "ts":
-		{
-			if strings.HasSuffix(s.Original, "test.ts") {
-				var o = createExecOption("npm", false)
-				o.CompileOptions = append([]string{"test"}, o.CompileOptions...)
-				o.Arguments = nil
-				exec.Execute(o)
-			} else {
-				if !option.IsOnlyExecuteMode {
-					var o = createExecOption("tsc", true)
-					o.CompileOptions = append([]string{"--build"}, option.CompileArgs...)
-					o.Arguments = nil
-					o.ExecOptions = nil
-					exec.Execute(o)
-				}
-				if !option.IsOnlyCompileMode {
-					var o = createExecOption("node", false)
-					o.CompileOptions = nil
-					o.Arguments = []string{fmt.Sprintf("%v/target/%v.js", s.Dir, s.Name)}
-					exec.Execute(o)
-				}
-			}
-			os.Exit(0)
+	var base = exec.Option{
+		Dir:                        s.Dir,
+		Stdout:                     s.Stdout,
+		Stderr:                     s.Stderr,
+		Context:                    s.Context,
+		ExitStatusWhenCompileError: exitStatusWhenCompileError,
+		IsDebugMode:                isDebugMode,
+		MaxOutput:                  option.MaxOutput,
+	}
+
+	if option.IsOnlyCompileMode {
+		fmt.Fprintln(s.Stderr, "\u001B[094mOnly-compile mode.\u001B[0m")
+	}
+
+	var c, err = config.Load()
+	if err != nil {
+		fmt.Fprintf(s.Stderr, "Failed to load the configuration: %v\n", err)
+		return finish(exitStatusWhenCompileError)
+	}
+
+	//profile
+	var profileName = ""
+	{
+		var profile, ok, err = c.Select(option.Profile, option.Source.Path)
+		if err != nil {
+			fmt.Fprintf(s.Stderr, "Failed to select the profile: %v\n", err)
+			return finish(exitStatusWhenCompileError)
+		}
+		if ok {
+			util.DebugPrint(profile, isDebugMode)
+			option = profile.Apply(option)
+			profileName = profile.Name
+			base.Env = profile.Environ()
 		}
+	}
 
-	case "c", "cpp":
-		{
-			var output = s.PathWoExt + ".out"
-			if !option.IsOnlyExecuteMode {
-				var o = func() exec.Option {
-
-					if runtime.GOOS == "darwin" {
-						if s.Ext == "c" {
-							return createExecOption("gcc-13", true)
-						}
-						return createExecOption("g++-13", true)
-					}
+	var diffOption = diff.Option{
+		Style:     option.DiffStyle,
+		IsColored: isColored,
+	}
 
-					if s.Ext == "c" {
-						return createExecOption("gcc", true)
-					}
-					return createExecOption("g++", true)
+	var diagnostics = make([]diagnostic.Diagnostic, 0)
+	var rep = report.New(option.Source.Path, "", profileName) //The runner is set when it's resolved.
 
-				}()
-				o.CompileOptions = append([]string{"-fdiagnostics-color=always", "-Wfatal-errors", "-o", output}, option.CompileArgs...)
-				if s.Ext == "c" {
-					o.CompileOptions = append(o.CompileOptions, "-l", "m")
-				}
-				o.ExecOptions = nil
-				exec.Execute(o)
+	//exit writes the diagnostics and the report, and returns the exit status.
+	var exit = func(exitStatus int) int {
+		if option.Report != "" {
+			rep.Diagnostics = diagnostics
+			if err := rep.Write(option.ReportFile, exitStatus); err != nil {
+				fmt.Fprintf(s.Stderr, "Failed to write the report: %v\n", err)
 			}
-			if !option.IsOnlyCompileMode {
-				var o = createExecOption(output, false)
-				o.CompileOptions = nil
-				o.Arguments = nil
-				exec.Execute(o)
+		}
+		if option.Quickfix == "-" {
+			fmt.Fprint(s.Stderr, diagnostic.Quickfix(diagnostics))
+		} else if option.Quickfix != "" {
+			if err := os.WriteFile(option.Quickfix, []byte(diagnostic.Quickfix(diagnostics)), 0644); err != nil {
+				fmt.Fprintf(s.Stderr, "Failed to write the quickfix file: %v\n", err)
 			}
-			os.Exit(0)
 		}
+		return finish(exitStatus)
+	}
 
-	case "java":
-		{
-			if util.IsFile("./settings.gradle") { //project
-				if !option.IsOnlyExecuteMode {
-					var o = createExecOption("gradle", true)
-					o.CompileOptions = append([]string{"build", "--quiet", "--console", "plain"}, option.CompileArgs...)
-					o.Arguments = nil
-					o.ExecOptions = nil
-					exec.Execute(o)
-				}
-				if !option.IsOnlyCompileMode {
-					var fqcn = func() string {
-						var packageName = regexp.MustCompile(`package ([^;]+);`).FindStringSubmatch(
-							strings.Join(util.ReadFileUnchecked(s.Path), "\n"),
-						)[1]
-						return fmt.Sprintf("%v.%v", packageName, s.Name)
-					}()
-					var o = createExecOption("java", false)
-					o.CompileOptions = []string{
-						"-enableassertions",
-						"--class-path",
-						"./app/build/classes/java/main:./app/build/classes/java/test/",
-					}
-					o.Arguments = []string{fqcn}
-					exec.Execute(o)
-				}
-			} else { //non-project (unit file)
-				if !option.IsOnlyExecuteMode {
-					var o = createExecOption("javac", true)
-					o.ExecOptions = nil
-					exec.Execute(o)
-				}
-				if !option.IsOnlyCompileMode {
-					var o = createExecOption("java", false)
-					o.CompileOptions = []string{"-enableassertions"}
-					o.Arguments = []string{s.Name}
-					exec.Execute(o)
+	if option.Subcommand == "stress" {
+		return exit(stress.Run(option, base, diffOption, rep))
+	}
+
+	if option.Subcommand == "bundle" {
+		return exit(bundle.Run(option, c, base, rep))
+	}
+
+	//yrun.sh
+	//We respect `yrun.sh` iff the following three conditions are met.
+	//1. It exists.
+	//2. It isn't empty.
+	//3. It doesn't consist only of comments.
+	{
+		var file = "./yrun.sh"
+		var path = filepath.Join(s.Dir, file)
+		if util.IsFile(path) {
+
+			//checks if `./yrun.sh` is empty
+			var lines = util.ReadFileUnchecked(path)
+			var comment, _ = runner.Comment("sh")
+			var isYrunShEmpty = true
+			for _, line := range lines {
+				var l = strings.TrimSpace(line)
+				if !((l == "") || strings.HasPrefix(l, comment.Line)) {
+					isYrunShEmpty = false
+					break
 				}
 			}
-			os.Exit(0)
-		}
 
-	case "hs":
-		{
-			var cabalFiles, _ = filepath.Glob("*.cabal")
-			if cabalFiles != nil { //project
-				if strings.Contains(s.Path, "/test/") { //test files
-					var o = createExecOption("cabal", true)
-					o.CompileOptions = append([]string{"test", "-v0", "--test-show-details=streaming", "--test-option=--color", "--ghc-options=-Wall"}, option.CompileArgs...)
-					o.Arguments = nil
-					o.ExecOptions = nil
-					exec.Execute(o)
-				} else {
-					var packageName = regexp.MustCompile(`\.cabal$`).ReplaceAllString(cabalFiles[0], "")
-					if !option.IsOnlyExecuteMode {
-						var o = createExecOption("cabal", true)
-						o.CompileOptions = append([]string{"build", "-v0", "--ghc-options=-Wall"}, option.CompileArgs...)
-						o.Arguments = nil
-						o.ExecOptions = nil
-						exec.Execute(o)
-					}
-					if !option.IsOnlyCompileMode && (s.Base == "Main.hs") {
-						var o = createExecOption("cabal", false)
-						o.CompileOptions = []string{"exec", packageName}
-						o.Arguments = nil
-						exec.Execute(o)
-					}
+			if !isYrunShEmpty {
+				var o = base
+				o.Command = "bash"
+				o.Arguments = []string{file, option.Source.Path}
+				o.ExecOptions = option.ExecArgs
+				o.ShouldMeasureTime = option.ShouldMeasureTime
+				if s.Stdin != nil {
+					o.Stdin = bytes.NewReader(s.Stdin)
 				}
-			} else {
-				var output = s.PathWoExt + ".out"
-				if !option.IsOnlyExecuteMode {
-					var o = createExecOption("ghc", true)
-					o.CompileOptions = append([]string{"-v0", "-Wall", "-Wno-type-defaults", "-o", output}, option.CompileArgs...)
-					o.ExecOptions = nil
-					exec.Execute(o)
+				if emitter != nil {
+					o.Stdout, o.Stderr = emitter.Stdout(), emitter.Stderr()
+					emitter.PhaseStarted(o)
 				}
-				if !option.IsOnlyCompileMode {
-					var o = createExecOption(output, false)
-					o.CompileOptions = nil
-					o.Arguments = nil
-					exec.Execute(o)
+				var result, exitStatus = exec.Execute(o)
+				if emitter != nil {
+					emitter.PhaseFinished(o, result, exitStatus, false)
 				}
+				return finish(exitStatus)
 			}
-			os.Exit(0)
+
 		}
+	}
 
-	case "go":
-		{
-			if strings.HasSuffix(s.Base, "_test.go") { //test files
-				var packageName = strings.Split(s.Base, "_")[0]
-				var o = createExecOption("go", true)
-				o.CompileOptions = append([]string{"test", "--count=1", "-v", fmt.Sprintf("./%v", packageName)}, option.CompileArgs...)
-				o.Arguments = nil
-				o.ExecOptions = nil
-				exec.Execute(o)
-			} else { //normal files
-				if util.IsFile("./go.mod") { //project
-					if !option.IsOnlyExecuteMode {
-						var o = createExecOption("go", true)
-						o.CompileOptions = append([]string{"build"}, option.CompileArgs...)
-						o.Arguments = nil
-						o.ExecOptions = nil
-						exec.Execute(o)
-					}
-					if !option.IsOnlyCompileMode && (s.Base == "main.go") {
-						var output = func() string {
-							var moduleName = regexp.MustCompile(`^module (.*)$`).FindStringSubmatch(
-								util.ReadFileUnchecked("./go.mod")[0],
-							)[1]
-							return fmt.Sprintf("./%v", moduleName)
-						}()
-						var o = createExecOption(output, false)
-						o.CompileOptions = nil
-						o.Arguments = nil
-						exec.Execute(o)
-					}
-				} else { //non-project (unit file)
-					var output = s.PathWoExt + ".out"
-					if !option.IsOnlyExecuteMode {
-						var o = createExecOption("go", true)
-						o.CompileOptions = append([]string{"build", "-o", output}, option.CompileArgs...)
-						o.ExecOptions = nil
-						exec.Execute(o)
-					}
-					if !option.IsOnlyCompileMode {
-						var o = createExecOption(output, false)
-						o.CompileOptions = nil
-						o.Arguments = nil
-						exec.Execute(o)
+	r, err := runner.Resolve(option, base)
+	if err != nil {
+		fmt.Fprintf(s.Stderr, "Failed to resolve the runner: %v\n", err)
+		return finish(exitStatusWhenCompileError)
+	}
+	rep.Runner = r.Name
+
+	//execute runs `steps` in order and returns the exit status of the first failed one.
+	var execute = func(steps []exec.Option) int {
+		for _, o := range steps {
+			//Each command gets the whole input, as the compilers don't read it.
+			if s.Stdin != nil {
+				o.Stdin = bytes.NewReader(s.Stdin)
+			}
+			var stdoutWriter, stderrWriter = s.Stdout, s.Stderr
+			if emitter != nil {
+				stdoutWriter, stderrWriter = emitter.Stdout(), emitter.Stderr()
+				o.Stdout, o.Stderr = stdoutWriter, stderrWriter
+			}
+			//The output is copied for the diagnostics: both stdout and stderr of compilers, and only stderr of programs.
+			var stdout, stderr bytes.Buffer
+			if (option.Quickfix != "") || (option.Report != "") || (emitter != nil) {
+				o.Stderr = io.MultiWriter(stderrWriter, &stderr)
+				if o.IsCompileMode {
+					o.Stdout = io.MultiWriter(stdoutWriter, &stdout)
+					if isColored && (o.Command == "cargo") {
+						o.Env = append(o.Env, "CARGO_TERM_COLOR=always") //Cargo stops coloring as its output is no longer a terminal.
 					}
 				}
 			}
-			os.Exit(0)
-		}
-
-	case "rs":
-		{
-			if !util.IsFile("./Cargo.toml") {
-				util.Eprintln("`Cargo.toml` not found. `cd ..` in Vim may help.")
-				os.Exit(exitStatusWhenCompileError)
+			if emitter != nil {
+				emitter.PhaseStarted(o)
 			}
-			if s.Base == "main.rs" {
-				if strings.Contains(s.Path, "/atcoder/") {
-					os.Setenv("RUST_BACKTRACE", "0")
-					var o = createExecOption("cargo", true)
-					o.CompileOptions = append([]string{"test"}, option.CompileArgs...)
-					o.Arguments = nil
-					o.ExecOptions = nil
-					exec.Execute(o)
-				} else {
-					if !option.IsOnlyExecuteMode {
-						var o = createExecOption("cargo", true)
-						o.CompileOptions = append([]string{"build"}, option.CompileArgs...)
-						o.Arguments = nil
-						o.ExecOptions = nil
-						exec.Execute(o)
-					}
-					if !option.IsOnlyCompileMode {
-						var output = func() string {
-							var packageName = regexp.MustCompile(`^name = "(.*)"$`).FindStringSubmatch(
-								util.ReadFileUnchecked("./Cargo.toml")[1],
-							)[1]
-							return fmt.Sprintf("./target/debug/%v", packageName)
-						}()
-						var o = createExecOption(output, false)
-						o.CompileOptions = nil
-						o.Arguments = nil
-						exec.Execute(o)
-					}
+			var result, exitStatus, isCached = cache.Execute(o, option.ShouldForceRebuild)
+			if emitter != nil {
+				emitter.PhaseFinished(o, result, exitStatus, isCached)
+			}
+			if isCached {
+				continue
+			}
+			rep.Add(o, result)
+			var l = diagnostic.Parse(o.Command, stderr.String()+stdout.String(), o.WorkingDir())
+			if (len(l) == 0) && (exitStatus != 0) {
+				l = diagnostic.ParseRuntime(stderr.String()+stdout.String(), o.WorkingDir())
+			}
+			if emitter != nil {
+				for _, d := range l {
+					emitter.Diagnostic(d)
 				}
-			} else {
-				var o = createExecOption("cargo", true)
-				o.CompileOptions = append([]string{"check", "--quiet"}, option.CompileArgs...)
-				o.Arguments = nil
-				o.ExecOptions = nil
-				exec.Execute(o)
 			}
-			os.Exit(0)
+			diagnostics = append(diagnostics, l...)
+			if exitStatus != 0 {
+				return exitStatus
+			}
 		}
+		return 0
+	}
 
-	case "dart":
-		{
-			if strings.HasSuffix(s.Base, "_test.dart") { //test files
-				var o = createExecOption("dart", true)
-				o.CompileOptions = append([]string{"test"}, option.CompileArgs...)
-				o.Arguments = nil
-				o.ExecOptions = nil
-				exec.Execute(o)
-			} else { //normal files
-				if util.IsFile("./pubspec.yaml") { //project
-					var o = createExecOption("dart", true)
-					o.CompileOptions = append([]string{"run", "--enable-asserts"}, option.CompileArgs...)
-					o.Arguments = nil
-					o.ExecOptions = nil
-					exec.Execute(o)
-				} else { //non-project (unit file)
-					var output = s.PathWoExt + ".out"
-					if !option.IsOnlyExecuteMode {
-						var o = createExecOption("dart", true)
-						o.CompileOptions = append([]string{"compile", "exe", "--verbosity", "warning", "-o", output}, option.CompileArgs...)
-						o.ExecOptions = nil
-						exec.Execute(o)
-					}
-					if !option.IsOnlyCompileMode {
-						var o = createExecOption(output, false)
-						o.CompileOptions = nil
-						o.Arguments = nil
-						exec.Execute(o)
+	//test cases
+	//When the source embeds test cases in its comments (see `judge.ParseInline`) or a test-case directory is specified,
+	//we run the program for each of them instead of just once.
+	if !option.IsOnlyCompileMode {
+		var cases = make([]judge.Case, 0)
+		if option.TestDir != "" {
+			var l, err = judge.LoadDir(option.TestDir)
+			if err != nil {
+				fmt.Fprintf(s.Stderr, "Failed to read the test cases: %v\n", err)
+				return finish(exitStatusWhenCompileError)
+			}
+			cases = append(cases, l...)
+		}
+		if comment, ok := runner.Comment(option.Source.Ext); ok {
+			cases = append(cases, judge.ParseInline(util.ReadFileUnchecked(option.Source.Path), comment)...)
+		}
+		if len(cases) != 0 {
+			if build, program, err := r.Program(); err == nil {
+				if exitStatus := execute(build); exitStatus != 0 {
+					return exit(exitStatus)
+				}
+				var o = judge.Option{
+					Jobs:      option.Jobs,
+					TimeLimit: option.TimeLimit,
+					Diff:      diffOption,
+					Stdout:    s.Stdout,
+					Stderr:    s.Stderr,
+				}
+				o.OnCase = func(name string, verdict judge.Verdict, result exec.Result) {
+					rep.AddCase(name, string(verdict), program, result)
+					if emitter != nil {
+						emitter.CaseFinished(name, string(verdict), result)
 					}
 				}
+				if emitter != nil {
+					o.Stdout, o.Stderr = emitter.Stdout(), emitter.Stderr()
+				}
+				return exit(judge.Run(program, cases, o))
 			}
-			os.Exit(0)
-		}
-
-	default:
-		{
-			util.Eprintf("Unsupported file type: %v\n", s.Ext)
-			os.Exit(exitStatusWhenCompileError)
+			util.DebugPrint("Test cases are ignored as the program cannot be run by itself.", isDebugMode)
 		}
-
 	}
 
+	return exit(execute(r.Steps))
+
 }
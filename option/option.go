@@ -2,19 +2,72 @@ package option
 
 import "os"
 import "fmt"
+import "path/filepath"
+import "strconv"
 import "strings"
+import "time"
 
 import "golang.org/x/exp/slices"
 
+import "executer/diff"
+import "executer/exec"
+import "executer/protocol"
+import "executer/report"
 import "executer/source"
 
 type Options struct {
-	Source            source.Source
-	CompileArgs       []string
-	ExecArgs          []string
-	IsOnlyCompileMode bool
-	IsOnlyExecuteMode bool
-	ShouldMeasureTime bool
+	Subcommand                string //empty when no subcommand is specified
+	Source                    source.Source
+	Lang                      string //language of the source read from stdin (`-`)
+	CompileArgs               []string
+	ExecArgs                  []string
+	IsOnlyCompileMode         bool
+	IsOnlyExecuteMode         bool
+	ShouldForceRebuild        bool
+	ShouldPutArtifactsInPlace bool
+	ShouldMeasureTime         bool
+	ShouldWatch               bool
+	ShouldServe               bool
+	ReadyPort                 int //for `--serve` (`0` if not specified)
+	DiffStyle                 diff.Style
+	Jobs                      int
+	TimeLimit                 time.Duration //no limit if zero
+	Profile                   string        //name of the profile specified explicitly
+	ShouldDetectRace          bool          //for Go
+	Bench                     string        //pattern of the benchmarks to run, for Go (no benchmarks if empty)
+	Fuzz                      string        //fuzz target, for Go
+	FuzzTime                  time.Duration //for `--fuzz` (the default of the runner if zero)
+	ShouldCover               bool          //for Go
+	CoverHTML                 string        //file to write the coverage report in HTML to, for Go
+	Mode                      string
+	TestDir                   string //directory containing `*.in` and `*.out`
+	Line                      int    //line of the test to run (all the tests if `0`)
+	Quickfix                  string //file to write the diagnostics to (`-` for stderr)
+	Report                    string //format of the report (no report if empty)
+	ReportFile                string
+	MaxOutput                 exec.OutputLimit
+	Protocol                  string        //format of the events written to stdout (plain output if empty)
+	Generator                 source.Source //for `stress`
+	Reference                 source.Source //for `stress`
+	Iterations                int           //for `stress` (`0` means unlimited)
+	Output                    string        //for `bundle` (stdout if empty)
+	IsDryRun                  bool          //for `clean`
+	Socket                    string        //for `daemon`
+	HistoryIndex              int           //for `history` and `rerun` (`0` if not specified)
+}
+
+const (
+	ModeRun  = "run"  //compiles and executes the program (default)
+	ModeTest = "test" //runs the tests instead (e.g. `cargo test` for `main.rs`)
+)
+
+var subcommandList = []string{
+	"stress",
+	"bundle",
+	"history",
+	"rerun",
+	"clean",
+	"daemon",
 }
 
 var optionList = []string{
@@ -22,7 +75,38 @@ var optionList = []string{
 	"--args",
 	"--only-compile",
 	"--only-execute",
+	"--force-rebuild",
+	"--artifacts-in-place",
 	"--time",
+	"--diff",
+	"--jobs",
+	"--time-limit",
+	"--profile",
+	"--mode",
+	"--test-dir",
+	"--quickfix",
+	"--report",
+	"--report-file",
+	"--max-output",
+	"--on-max-output",
+	"--gen",
+	"--ref",
+	"--iterations",
+	"--output",
+	"--dry-run",
+	"--watch",
+	"--serve",
+	"--ready-port",
+	"--socket",
+	"--protocol",
+	"--lang",
+	"--line",
+	"--race",
+	"--bench",
+	"--fuzz",
+	"--fuzz-time",
+	"--cover",
+	"--cover-html",
 	"-h",
 	"--help",
 }
@@ -45,24 +129,141 @@ func extractArgumentsToOption(args []string, i int) ([]string, int) {
 
 }
 
+func extractSingleArgumentToOption(args []string, i int) (string, int, error) {
+	if (i == len(args)-1) || slices.Contains(optionList, args[i+1]) {
+		return "", i, fmt.Errorf("no argument specified to option: [ %v ]", args[i])
+	}
+	return args[i+1], i + 1, nil
+}
+
 func printUsage() {
 	fmt.Println(`Usage
   executer <file> [<option(s)>]
+  executer - --lang <lang> [<option(s)>]
+  executer stress --gen <generator> --ref <reference> <file> [<option(s)>]
+  executer bundle <file> [--output <file>] [<option(s)>]
+  executer history [<n>]
+  executer rerun [<n>]
+  executer clean [<file or dir>] [--dry-run]
+  executer daemon [--socket <file>]
+
+Subcommands
+  stress                       #Repeatedly compares the outputs of <file> and <reference> for the inputs
+                               #printed by <generator>, which receives a seed as its first argument.
+                               #The first failing input is saved to <file without extension>.stress.in.
+  bundle                       #Inlines the local headers of C/C++, or the modules and the library crate
+                               #of Rust, into a single file, and checks that it compiles.
+                               #Libraries are configured with "cpp_include_dirs" and "rust_library" in
+                               #the configuration file (see "Profiles").
+  history                      #Lists the invocations recorded in $XDG_STATE_HOME/executer/history.jsonl with
+                               #their numbers, or shows the details of the one numbered <n>.
+                               #The numbers are kept when the old invocations are dropped.
+  rerun                        #Runs the invocation numbered <n> in the history (default: the latest one) again
+                               #in the same directory with the same options.
+  clean                        #Removes the executables, the intermediate files and the cache entries created for
+                               #<file> or the sources in <dir> (default: the current directory). Only the files
+                               #recorded in the cache (e.g. a.out for a.cpp by --artifacts-in-place), and the
+                               #files next to the sources run by executer (e.g. A.class for A.java) which aren't
+                               #modified after the last run in the history, are removed.
+  daemon                       #Listens on a Unix socket for JSON-RPC 2.0 requests, one per line, to run executer
+                               #in the background. The methods are:
+                               #  run {"args": [<arg(s)>], "cwd": <dir>, "stdin": <string>} -> {"run_id": <id>}
+                               #  cancel {"run_id": <id>}, status {"run_id": <id>}, list {}
+                               #The output and the result of a run are notified as "output" and "finished".
+                               #The runs share the daemon process. Subcommands and --watch aren't supported in "args".
 
 Options
+  --lang <lang>                #Reads the source from stdin when <file> is "-". <lang> is an extension (e.g. "cpp")
+                               #or a name (e.g. "rust"). The source is saved in a temporary directory, where it's run.
   --compile-args [<arg(s)>]    #Passes <arg(s)> when compilation.
   --args [<arg(s)>]            #Passes <arg(s)> when execution.
   --only-compile               #Just compiles and skips execution.
   --only-execute               #Just executes and skips compilation.
+  --force-rebuild              #Compiles even if the cached artifact for the same source, compiler and options exists.
+                               #(only for the unit files of C, C++, Haskell, Go and Dart)
+  --artifacts-in-place         #Puts the executables of the unit files next to them as <file without extension>.out
+                               #(and the intermediate files of ghc as well) instead of $XDG_CACHE_HOME/executer/.
   --time                       #Measures the execution time.
-  -h/--help                    #Shows this help.`)
+  --watch                      #Compiles and executes again whenever the source (or a file in the project) changes,
+                               #canceling the execution in progress.
+  --serve                      #Keeps a long-running program (e.g. a web server) running like --watch, and also
+                               #restarts it with backoff when it exits. Signals such as SIGTERM are passed to it.
+  --ready-port <port>          #Announces "ready" once <port> on localhost accepts TCP connections. (with --serve)
+  --diff <style>               #Shows mismatched outputs in <style>, which is "unified" (default) or "side-by-side".
+  --jobs <n>                   #Runs <n> test cases concurrently. (default: 1)
+  --time-limit <seconds>       #Judges the test cases taking more CPU time than <seconds> as TLE.
+  --profile <name>             #Uses the profile <name> instead of the one matching <file>.
+  --mode <mode>                #Runs the program when "run", or runs the tests when "test".
+                               #(only for the binaries and the examples of Rust, e.g. main.rs)
+  --line <n>                   #Runs only the test enclosing the line <n>, instead of all the tests in the file.
+                               #(Go tests, Rust #[test], hspec, Dart and Jest)
+  --race                       #Enables the race detector of Go.
+  --bench[=<pattern>]          #Runs the benchmarks of the Go package matching <pattern> (default: all) with
+                               #the memory allocation statistics, instead of the tests.
+  --fuzz <target>              #Fuzzes the Go package with the fuzz test <target> for the duration specified by
+                               #--fuzz-time, instead of running the tests.
+  --fuzz-time <duration>       #(default: 30s) <duration> is e.g. "1m30s".
+  --cover                      #Runs the tests of the Go package and prints the coverage of each function.
+  --cover-html <file>          #Writes the coverage report in HTML to <file> as well. (implies --cover)
+  --test-dir <dir>             #Runs the program for each <dir>/*.in and compares the output with *.out.
+  --quickfix <file>            #Writes the diagnostics of the compiler, or the location of a runtime error,
+                               #to <file> in the form of "<file>:<line>:<column>: <severity>: <message>",
+                               #or to stderr if <file> is "-".
+  --report <format>            #Writes a report of the commands, their results and the diagnostics in <format>,
+                               #which is "json", to the file specified by --report-file.
+  --report-file <file>         #(default: $XDG_STATE_HOME/executer/report.json)
+  --protocol <format>          #Writes the events (e.g. the start and the end of the compilation and the execution,
+                               #the output and the diagnostics) to stdout in <format> instead of the plain output.
+                               #<format> is "jsonl", where each line is a JSON object such as
+                               #{"event": "stdout", "time": <time>, "data": <string>}. Bytes which aren't valid
+                               #UTF-8 are in "data_base64" instead of "data". Not for a subcommand or --watch.
+  --max-output <limit>         #Stops the output after <limit>, which is <n> bytes, <n>k, <n>m or <n>lines.
+  --on-max-output <action>     #Kills the program ("kill", default) or discards the rest ("discard") at the limit.
+  --gen <file>                 #Specifies the generator for stress.
+  --ref <file>                 #Specifies the reference solution for stress.
+  --iterations <n>             #Stops stress after <n> iterations. (default: unlimited)
+  --output <file>              #Writes the result of bundle to <file> instead of stdout.
+  --dry-run                    #Lists the files which clean would remove without removing them.
+  --socket <file>              #Listens on <file> for daemon. (default: $XDG_RUNTIME_DIR/executer/daemon.sock)
+  -h/--help                    #Shows this help.
+
+Profiles
+  Profiles defined in $XDG_CONFIG_HOME/executer/config.json set the defaults of some options
+  for the sources whose paths match their globs. The first matching profile is used.
+    {"profiles": [{"name": "atcoder", "paths": ["**/atcoder/**"], "compile_args": {"cpp": ["-O2"]},
+                   "env": {"RUST_BACKTRACE": "0"}, "mode": "test", "test_dir": "tests"}]}
+  "compile_args" are keyed by the extension of the source. Without the file, only the profile "atcoder" above
+  is defined, with "paths" ["**/atcoder/**/main.rs"] and without "compile_args" and "test_dir".
+
+Inline test cases
+  When <file> embeds test cases in its comments as below, the program is run for each of them
+  and the verdicts are reported. "@output" is optional.
+    /* @input                    # @input
+    1 2                          # 1 2
+    @output                      # @output
+    3                            # 3
+    */`)
 }
 
 var exit func(int) = os.Exit //for mock
 
+// Parse parses the command-line arguments `args`, whose first element is the program name.
 func Parse(args []string) (Options, error) {
+	var cwd, _ = os.Getwd()
+	return ParseIn(args, cwd)
+}
 
-	var ret = Options{}
+// ParseIn is `Parse` with the relative paths in `args` resolved against `dir` instead of the current directory.
+func ParseIn(args []string, dir string) (Options, error) {
+
+	var ret = Options{DiffStyle: diff.Unified, Jobs: 1}
+
+	var abs = func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
 
 	if len(args) == 1 {
 		printUsage()
@@ -70,6 +271,10 @@ func Parse(args []string) (Options, error) {
 	}
 
 	var i = 0
+	if (len(args) > 1) && slices.Contains(subcommandList, args[1]) {
+		ret.Subcommand = args[1]
+		i++
+	}
 	for i < len(args)-1 {
 		i++
 		var arg = args[i]
@@ -85,31 +290,231 @@ func Parse(args []string) (Options, error) {
 		case "--only-execute":
 			ret.IsOnlyExecuteMode = true
 
+		case "--force-rebuild":
+			ret.ShouldForceRebuild = true
+
+		case "--artifacts-in-place":
+			ret.ShouldPutArtifactsInPlace = true
+
+		case "--dry-run":
+			ret.IsDryRun = true
+
+		case "--watch":
+			ret.ShouldWatch = true
+
+		case "--serve":
+			ret.ShouldWatch = true
+			ret.ShouldServe = true
+
 		case "--time":
 			ret.ShouldMeasureTime = true
 
+		case "--race":
+			ret.ShouldDetectRace = true
+
+		case "--cover":
+			ret.ShouldCover = true
+
+		//The pattern is optional, so it's given as `--bench=<pattern>` to be told from the source.
+		case "--bench":
+			ret.Bench = "."
+
 		case "--args":
 			ret.ExecArgs, i = extractArgumentsToOption(args, i)
 
 		case "--compile-args":
 			ret.CompileArgs, i = extractArgumentsToOption(args, i)
 
+		case "--diff", "--jobs", "--time-limit", "--profile", "--mode", "--test-dir", "--quickfix", "--report", "--report-file", "--max-output", "--on-max-output", "--gen", "--ref", "--iterations", "--output", "--ready-port", "--socket", "--protocol", "--lang", "--line", "--fuzz", "--fuzz-time", "--cover-html":
+			var value string
+			var err error
+			value, i, err = extractSingleArgumentToOption(args, i)
+			if err != nil {
+				return ret, err
+			}
+			switch arg {
+			case "--diff":
+				ret.DiffStyle = diff.Style(value)
+				if !slices.Contains(diff.Styles, ret.DiffStyle) {
+					return ret, fmt.Errorf("unknown diff style: [ %v ]", value)
+				}
+			case "--jobs":
+				if ret.Jobs, err = strconv.Atoi(value); (err != nil) || (ret.Jobs <= 0) {
+					return ret, fmt.Errorf("invalid number of jobs: [ %v ]", value)
+				}
+			case "--time-limit":
+				var seconds float64
+				if seconds, err = strconv.ParseFloat(value, 64); (err != nil) || (seconds <= 0) {
+					return ret, fmt.Errorf("invalid time limit: [ %v ]", value)
+				}
+				ret.TimeLimit = time.Duration(seconds * float64(time.Second))
+			case "--profile":
+				ret.Profile = value
+			case "--mode":
+				if (value != ModeRun) && (value != ModeTest) {
+					return ret, fmt.Errorf("unknown mode: [ %v ]", value)
+				}
+				ret.Mode = value
+			case "--test-dir":
+				ret.TestDir = abs(value)
+			case "--output":
+				ret.Output = value
+			case "--quickfix":
+				ret.Quickfix = value
+				if value != "-" {
+					ret.Quickfix = abs(value)
+				}
+			case "--report":
+				if !slices.Contains(report.Formats, value) {
+					return ret, fmt.Errorf("unknown report format: [ %v ]", value)
+				}
+				ret.Report = value
+			case "--report-file":
+				ret.ReportFile = abs(value)
+			case "--max-output":
+				var isDiscard = ret.MaxOutput.IsDiscard
+				if ret.MaxOutput, err = exec.ParseOutputLimit(value); err != nil {
+					return ret, err
+				}
+				ret.MaxOutput.IsDiscard = isDiscard
+			case "--on-max-output":
+				if (value != "kill") && (value != "discard") {
+					return ret, fmt.Errorf("unknown action: [ %v ]", value)
+				}
+				ret.MaxOutput.IsDiscard = value == "discard"
+			case "--gen":
+				ret.Generator = source.NewIn(value, dir)
+			case "--ref":
+				ret.Reference = source.NewIn(value, dir)
+			case "--ready-port":
+				if ret.ReadyPort, err = strconv.Atoi(value); (err != nil) || (ret.ReadyPort <= 0) || (ret.ReadyPort > 65535) {
+					return ret, fmt.Errorf("invalid port: [ %v ]", value)
+				}
+			case "--socket":
+				ret.Socket = abs(value)
+			case "--protocol":
+				if !slices.Contains(protocol.Formats, value) {
+					return ret, fmt.Errorf("unknown protocol: [ %v ]", value)
+				}
+				ret.Protocol = value
+			case "--lang":
+				ret.Lang = value
+			case "--line":
+				if ret.Line, err = strconv.Atoi(value); (err != nil) || (ret.Line <= 0) {
+					return ret, fmt.Errorf("invalid line number: [ %v ]", value)
+				}
+			case "--fuzz":
+				ret.Fuzz = value
+			case "--fuzz-time":
+				if ret.FuzzTime, err = time.ParseDuration(value); (err != nil) || (ret.FuzzTime <= 0) {
+					return ret, fmt.Errorf("invalid fuzz time: [ %v ]", value)
+				}
+			case "--cover-html":
+				ret.CoverHTML = abs(value)
+				ret.ShouldCover = true
+			case "--iterations":
+				if ret.Iterations, err = strconv.Atoi(value); (err != nil) || (ret.Iterations <= 0) {
+					return ret, fmt.Errorf("invalid number of iterations: [ %v ]", value)
+				}
+			}
+
 		default:
-			if strings.HasPrefix(arg, "-") {
+			if strings.HasPrefix(arg, "--bench=") {
+				if ret.Bench = strings.TrimPrefix(arg, "--bench="); ret.Bench == "" {
+					return ret, fmt.Errorf("the pattern of `--bench=` is empty")
+				}
+				continue
+			}
+			if strings.HasPrefix(arg, "-") && (arg != "-") { //`-` is stdin
 				return ret, fmt.Errorf("unknown option: [ %v ]", arg)
 			}
+			if (ret.Subcommand == "history") || (ret.Subcommand == "rerun") {
+				var err error
+				if ret.HistoryIndex, err = strconv.Atoi(arg); (err != nil) || (ret.HistoryIndex <= 0) {
+					return ret, fmt.Errorf("invalid index of the history: [ %v ]", arg)
+				}
+				continue
+			}
 			if !ret.Source.IsEmpty() {
 				return ret, fmt.Errorf("more than one sources specified: [ %v, %v ]", ret.Source, arg)
 			}
-			ret.Source = source.New(arg)
+			ret.Source = source.NewIn(arg, dir)
 
 		}
 	}
 
+	if (ret.Bench != "") && (ret.Fuzz != "") {
+		return ret, fmt.Errorf("`--bench` and `--fuzz` cannot be used together")
+	}
+
+	if (ret.Line != 0) && ((ret.Bench != "") || (ret.Fuzz != "")) {
+		return ret, fmt.Errorf("`--line` cannot be used with `--bench` or `--fuzz`")
+	}
+
+	if (ret.Fuzz == "") && (ret.FuzzTime != 0) {
+		return ret, fmt.Errorf("`--fuzz-time` is only for `--fuzz`")
+	}
+
+	if !ret.ShouldServe && (ret.ReadyPort != 0) {
+		return ret, fmt.Errorf("`--ready-port` is only for `--serve`")
+	}
+
+	if (ret.Subcommand != "") && ret.ShouldWatch {
+		return ret, fmt.Errorf("`--watch` cannot be used with `%v`", ret.Subcommand)
+	}
+
+	if (ret.Subcommand != "daemon") && (ret.Socket != "") {
+		return ret, fmt.Errorf("`--socket` is only for `daemon`")
+	}
+
+	//The events are emitted only for a plain run.
+	if (ret.Protocol != "") && ((ret.Subcommand != "") || ret.ShouldWatch) {
+		return ret, fmt.Errorf("`--protocol` cannot be used with a subcommand or `--watch`")
+	}
+
+	if ret.Source.Original == "-" {
+		if ret.Lang == "" {
+			return ret, fmt.Errorf("`--lang` is required to read the source from stdin")
+		}
+		if (ret.Subcommand != "") || ret.ShouldWatch {
+			return ret, fmt.Errorf("the source cannot be read from stdin with a subcommand or `--watch`")
+		}
+	} else if ret.Lang != "" {
+		return ret, fmt.Errorf("`--lang` is only for `-`")
+	}
+
+	if (ret.Subcommand == "history") || (ret.Subcommand == "rerun") || (ret.Subcommand == "daemon") {
+		return ret, nil
+	}
+
+	if ret.Subcommand == "clean" {
+		if ret.Source.IsEmpty() {
+			ret.Source = source.NewIn(".", dir)
+		}
+	} else if ret.IsDryRun {
+		return ret, fmt.Errorf("`--dry-run` is only for `clean`")
+	}
+
 	if ret.Source.IsEmpty() {
 		return ret, fmt.Errorf("no source specified")
 	}
 
+	if ret.Subcommand == "stress" {
+		if ret.Generator.IsEmpty() || ret.Reference.IsEmpty() {
+			return ret, fmt.Errorf("`stress` requires both `--gen` and `--ref`")
+		}
+	} else if !ret.Generator.IsEmpty() || !ret.Reference.IsEmpty() || (ret.Iterations != 0) {
+		return ret, fmt.Errorf("`--gen`, `--ref` and `--iterations` are only for `stress`")
+	}
+
+	if (ret.Report != "") && (ret.ReportFile == "") {
+		ret.ReportFile = report.DefaultFile()
+	}
+
+	if (ret.Subcommand != "bundle") && (ret.Output != "") {
+		return ret, fmt.Errorf("`--output` is only for `bundle`")
+	}
+
 	return ret, nil
 
 }
@@ -2,10 +2,14 @@ package option
 
 import "testing"
 import "fmt"
+import "path/filepath"
 import "strings"
+import "time"
 
 import "golang.org/x/exp/slices"
 
+import "executer/diff"
+
 func Test_misc(t *testing.T) {
 
 	t.Run("No option.", func(t *testing.T) {
@@ -166,3 +170,505 @@ func Test_source(t *testing.T) {
 	})
 
 }
+
+func Test_stress(t *testing.T) {
+
+	t.Run("`stress` with all the required options", func(t *testing.T) {
+
+		var args = []string{"$0", "stress", "--gen", "gen.py", "--ref", "brute.cpp", "main.cpp", "--iterations", "10"}
+
+		var ret, err = Parse(args)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !((ret.Subcommand == "stress") && (ret.Generator.Original == "gen.py") && (ret.Reference.Original == "brute.cpp") && (ret.Source.Original == "main.cpp") && (ret.Iterations == 10)) {
+			fmt.Println(ret)
+			t.FailNow()
+		}
+
+	})
+
+	t.Run("`stress` without `--ref`", func(t *testing.T) {
+
+		var args = []string{"$0", "stress", "--gen", "gen.py", "main.cpp"}
+
+		var _, err = Parse(args)
+		fmt.Println(err)
+
+		if (err == nil) || !strings.Contains(err.Error(), "requires both") {
+			t.Fatal(err)
+		}
+
+	})
+
+	t.Run("`--gen` without `stress`", func(t *testing.T) {
+
+		var args = []string{"$0", "main.cpp", "--gen", "gen.py"}
+
+		var _, err = Parse(args)
+		fmt.Println(err)
+
+		if (err == nil) || !strings.Contains(err.Error(), "only for") {
+			t.Fatal(err)
+		}
+
+	})
+
+	t.Run("`--gen` without argument", func(t *testing.T) {
+
+		var args = []string{"$0", "stress", "main.cpp", "--gen"}
+
+		var _, err = Parse(args)
+		fmt.Println(err)
+
+		if (err == nil) || !strings.HasPrefix(err.Error(), "no argument specified") {
+			t.Fatal(err)
+		}
+
+	})
+
+}
+
+func Test_diff(t *testing.T) {
+
+	t.Run("default", func(t *testing.T) {
+
+		var ret, err = Parse([]string{"$0", "main.go"})
+
+		if (err != nil) || (ret.DiffStyle != diff.Unified) {
+			t.Fatal(ret, err)
+		}
+
+	})
+
+	t.Run("`--diff side-by-side`", func(t *testing.T) {
+
+		var ret, err = Parse([]string{"$0", "main.go", "--diff", "side-by-side"})
+
+		if (err != nil) || (ret.DiffStyle != diff.SideBySide) {
+			t.Fatal(ret, err)
+		}
+
+	})
+
+	t.Run("unknown style", func(t *testing.T) {
+
+		var _, err = Parse([]string{"$0", "main.go", "--diff", "context"})
+		fmt.Println(err)
+
+		if (err == nil) || !strings.HasPrefix(err.Error(), "unknown diff style") {
+			t.Fatal(err)
+		}
+
+	})
+
+}
+
+func Test_profile(t *testing.T) {
+
+	t.Run("`--profile`, `--mode` and `--test-dir`", func(t *testing.T) {
+
+		var ret, err = Parse([]string{"$0", "main.rs", "--profile", "contest", "--mode", "test", "--test-dir", "tests"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !((ret.Profile == "contest") && (ret.Mode == ModeTest) && strings.HasPrefix(ret.TestDir, "/") && strings.HasSuffix(ret.TestDir, "/tests")) {
+			t.Fatal(ret)
+		}
+
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+
+		var _, err = Parse([]string{"$0", "main.rs", "--mode", "bench"})
+		fmt.Println(err)
+
+		if (err == nil) || !strings.HasPrefix(err.Error(), "unknown mode") {
+			t.Fatal(err)
+		}
+
+	})
+
+}
+
+func Test_bundle(t *testing.T) {
+
+	t.Run("`bundle` with `--output`", func(t *testing.T) {
+
+		var ret, err = Parse([]string{"$0", "bundle", "main.cpp", "--output", "submit.cpp"})
+
+		if (err != nil) || !((ret.Subcommand == "bundle") && (ret.Output == "submit.cpp")) {
+			t.Fatal(ret, err)
+		}
+
+	})
+
+	t.Run("`--output` without `bundle`", func(t *testing.T) {
+
+		var _, err = Parse([]string{"$0", "main.cpp", "--output", "submit.cpp"})
+		fmt.Println(err)
+
+		if (err == nil) || !strings.Contains(err.Error(), "only for `bundle`") {
+			t.Fatal(err)
+		}
+
+	})
+
+}
+
+func Test_jobs(t *testing.T) {
+
+	t.Run("default", func(t *testing.T) {
+
+		var ret, err = Parse([]string{"$0", "main.cpp"})
+
+		if (err != nil) || (ret.Jobs != 1) || (ret.TimeLimit != 0) {
+			t.Fatal(ret, err)
+		}
+
+	})
+
+	t.Run("`--jobs` and `--time-limit`", func(t *testing.T) {
+
+		var ret, err = Parse([]string{"$0", "main.cpp", "--jobs", "8", "--time-limit", "1.5"})
+
+		if (err != nil) || (ret.Jobs != 8) || (ret.TimeLimit != 1500*time.Millisecond) {
+			t.Fatal(ret, err)
+		}
+
+	})
+
+	t.Run("invalid number of jobs", func(t *testing.T) {
+
+		var _, err = Parse([]string{"$0", "main.cpp", "--jobs", "0"})
+		fmt.Println(err)
+
+		if (err == nil) || !strings.HasPrefix(err.Error(), "invalid number of jobs") {
+			t.Fatal(err)
+		}
+
+	})
+
+}
+
+func Test_report(t *testing.T) {
+
+	t.Run("`--report` without `--report-file`", func(t *testing.T) {
+
+		var ret, err = Parse([]string{"$0", "main.cpp", "--report", "json"})
+
+		if (err != nil) || (ret.Report != "json") || !strings.HasSuffix(ret.ReportFile, "/executer/report.json") {
+			t.Fatal(ret, err)
+		}
+
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+
+		var _, err = Parse([]string{"$0", "main.cpp", "--report", "xml"})
+		fmt.Println(err)
+
+		if (err == nil) || !strings.HasPrefix(err.Error(), "unknown report format") {
+			t.Fatal(err)
+		}
+
+	})
+
+}
+
+func Test_history(t *testing.T) {
+
+	t.Run("`history` without index", func(t *testing.T) {
+
+		var ret, err = Parse([]string{"$0", "history"})
+
+		if (err != nil) || (ret.Subcommand != "history") || (ret.HistoryIndex != 0) {
+			t.Fatal(ret, err)
+		}
+
+	})
+
+	t.Run("`rerun` with index", func(t *testing.T) {
+
+		var ret, err = Parse([]string{"$0", "rerun", "3"})
+
+		if (err != nil) || (ret.Subcommand != "rerun") || (ret.HistoryIndex != 3) {
+			t.Fatal(ret, err)
+		}
+
+	})
+
+	t.Run("invalid index", func(t *testing.T) {
+
+		var _, err = Parse([]string{"$0", "rerun", "main.go"})
+		fmt.Println(err)
+
+		if (err == nil) || !strings.HasPrefix(err.Error(), "invalid index") {
+			t.Fatal(err)
+		}
+
+	})
+
+}
+
+func Test_force_rebuild(t *testing.T) {
+
+	var ret, err = Parse([]string{"$0", "a.cpp", "--force-rebuild"})
+
+	if (err != nil) || !ret.ShouldForceRebuild {
+		t.Fatal(ret, err)
+	}
+
+}
+
+func Test_artifacts_in_place(t *testing.T) {
+
+	var ret, err = Parse([]string{"$0", "a.cpp", "--artifacts-in-place"})
+
+	if (err != nil) || !ret.ShouldPutArtifactsInPlace {
+		t.Fatal(ret, err)
+	}
+
+}
+
+func Test_clean(t *testing.T) {
+
+	t.Run("default target", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "clean", "--dry-run"})
+		if (err != nil) || (ret.Subcommand != "clean") || !ret.IsDryRun || (ret.Source.Original != ".") {
+			t.Fatal(ret, err)
+		}
+	})
+
+	t.Run("`--dry-run` without `clean`", func(t *testing.T) {
+		var _, err = Parse([]string{"$0", "a.cpp", "--dry-run"})
+		fmt.Println(err)
+		if err == nil {
+			t.FailNow()
+		}
+	})
+
+}
+
+func Test_watch(t *testing.T) {
+
+	t.Run("normal", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "a.cpp", "--watch"})
+		if (err != nil) || !ret.ShouldWatch {
+			t.Fatal(ret, err)
+		}
+	})
+
+	t.Run("with a subcommand", func(t *testing.T) {
+		var _, err = Parse([]string{"$0", "bundle", "a.cpp", "--watch"})
+		fmt.Println(err)
+		if err == nil {
+			t.FailNow()
+		}
+	})
+
+}
+
+func Test_serve(t *testing.T) {
+
+	t.Run("normal", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "server.go", "--serve", "--ready-port", "8080"})
+		if (err != nil) || !ret.ShouldServe || !ret.ShouldWatch || (ret.ReadyPort != 8080) {
+			t.Fatal(ret, err)
+		}
+	})
+
+	t.Run("`--ready-port` without `--serve`", func(t *testing.T) {
+		var _, err = Parse([]string{"$0", "server.go", "--ready-port", "8080"})
+		fmt.Println(err)
+		if err == nil {
+			t.FailNow()
+		}
+	})
+
+	t.Run("invalid port", func(t *testing.T) {
+		var _, err = Parse([]string{"$0", "server.go", "--serve", "--ready-port", "http"})
+		fmt.Println(err)
+		if err == nil {
+			t.FailNow()
+		}
+	})
+
+}
+
+func Test_daemon(t *testing.T) {
+
+	t.Run("normal", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "daemon", "--socket", "/tmp/d.sock"})
+		if (err != nil) || (ret.Subcommand != "daemon") || (ret.Socket != "/tmp/d.sock") {
+			t.Fatal(ret, err)
+		}
+	})
+
+	t.Run("`--socket` without `daemon`", func(t *testing.T) {
+		var _, err = Parse([]string{"$0", "a.cpp", "--socket", "/tmp/d.sock"})
+		fmt.Println(err)
+		if err == nil {
+			t.FailNow()
+		}
+	})
+
+}
+
+func Test_protocol(t *testing.T) {
+
+	t.Run("jsonl", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "a.cpp", "--protocol", "jsonl"})
+		if (err != nil) || (ret.Protocol != "jsonl") {
+			t.Fatal(ret, err)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		var _, err = Parse([]string{"$0", "a.cpp", "--protocol", "xml"})
+		fmt.Println(err)
+		if err == nil {
+			t.FailNow()
+		}
+	})
+
+	t.Run("with stress", func(t *testing.T) {
+		var _, err = Parse([]string{"$0", "stress", "a.cpp", "--gen", "g.py", "--ref", "r.cpp", "--protocol", "jsonl"})
+		fmt.Println(err)
+		if err == nil {
+			t.FailNow()
+		}
+	})
+
+	t.Run("with watch", func(t *testing.T) {
+		var _, err = Parse([]string{"$0", "a.cpp", "--watch", "--protocol", "jsonl"})
+		fmt.Println(err)
+		if err == nil {
+			t.FailNow()
+		}
+	})
+
+}
+
+func Test_stdin(t *testing.T) {
+
+	t.Run("normal", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "-", "--lang", "cpp", "--args", "1"})
+		if (err != nil) || (ret.Source.Original != "-") || (ret.Lang != "cpp") {
+			t.Fatal(ret, err)
+		}
+	})
+
+	t.Run("without `--lang`", func(t *testing.T) {
+		var _, err = Parse([]string{"$0", "-"})
+		fmt.Println(err)
+		if err == nil {
+			t.FailNow()
+		}
+	})
+
+	t.Run("`--lang` with a file", func(t *testing.T) {
+		var _, err = Parse([]string{"$0", "a.cpp", "--lang", "cpp"})
+		fmt.Println(err)
+		if err == nil {
+			t.FailNow()
+		}
+	})
+
+}
+
+func Test_line(t *testing.T) {
+
+	t.Run("normal", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "a_test.go", "--line", "12"})
+		if (err != nil) || (ret.Line != 12) {
+			t.Fatal(ret, err)
+		}
+	})
+
+	for _, value := range []string{"0", "-1", "a"} {
+		t.Run(value, func(t *testing.T) {
+			var _, err = Parse([]string{"$0", "a_test.go", "--line", value})
+			fmt.Println(err)
+			if err == nil {
+				t.FailNow()
+			}
+		})
+	}
+
+}
+
+func Test_ParseIn(t *testing.T) {
+
+	var ret, err = ParseIn([]string{"$0", "src/a.cpp", "--test-dir", "tests", "--quickfix", "-", "--report", "json", "--report-file", "/tmp/r.json"}, "/w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (ret.Source.Original != "src/a.cpp") || (ret.Source.Path != "/w/src/a.cpp") || (ret.TestDir != "/w/tests") {
+		t.Fatal(ret)
+	}
+	if (ret.Quickfix != "-") || (ret.ReportFile != "/tmp/r.json") {
+		t.Fatal(ret)
+	}
+
+}
+
+func Test_goModes(t *testing.T) {
+
+	t.Run("--bench without a pattern", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "a_test.go", "--bench", "--race"})
+		if (err != nil) || (ret.Bench != ".") || !ret.ShouldDetectRace {
+			t.Fatal(ret, err)
+		}
+	})
+
+	t.Run("--bench with a pattern", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "--bench=Sort", "a_test.go"})
+		if (err != nil) || (ret.Bench != "Sort") || (ret.Source.Original != "a_test.go") {
+			t.Fatal(ret, err)
+		}
+	})
+
+	t.Run("--bench followed by the source", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "--bench", "a_test.go"})
+		if (err != nil) || (ret.Bench != ".") || (ret.Source.Original != "a_test.go") {
+			t.Fatal(ret, err)
+		}
+	})
+
+	t.Run("--fuzz", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "a_test.go", "--fuzz", "FuzzA", "--fuzz-time", "1m"})
+		if (err != nil) || (ret.Fuzz != "FuzzA") || (ret.FuzzTime != time.Minute) {
+			t.Fatal(ret, err)
+		}
+	})
+
+	t.Run("--cover-html", func(t *testing.T) {
+		var ret, err = Parse([]string{"$0", "a_test.go", "--cover-html", "cover.html"})
+		if (err != nil) || !ret.ShouldCover || !filepath.IsAbs(ret.CoverHTML) {
+			t.Fatal(ret, err)
+		}
+	})
+
+	var invalid = [][]string{
+		{"$0", "a_test.go", "--bench", "--fuzz", "FuzzA"},
+		{"$0", "a_test.go", "--bench="},
+		{"$0", "a_test.go", "--fuzz", "FuzzA", "--line", "3"},
+		{"$0", "a_test.go", "--fuzz-time", "1m"},
+		{"$0", "a_test.go", "--fuzz", "FuzzA", "--fuzz-time", "1"},
+	}
+	for _, args := range invalid {
+		t.Run(strings.Join(args[2:], " "), func(t *testing.T) {
+			var _, err = Parse(args)
+			fmt.Println(err)
+			if err == nil {
+				t.FailNow()
+			}
+		})
+	}
+
+}
@@ -0,0 +1,174 @@
+package protocol
+
+import "encoding/base64"
+import "encoding/json"
+import "io"
+import "sync"
+import "time"
+import "unicode/utf8"
+
+import "executer/diagnostic"
+import "executer/exec"
+
+const (
+	FormatJSONL = "jsonl"
+)
+
+var Formats = []string{FormatJSONL}
+
+// Data holds bytes in JSON: as is in `Text` if they are valid UTF-8, or in `Base64` otherwise.
+// It is embedded in the messages so that the fields appear inline.
+type Data struct {
+	Text   string `json:"data,omitempty"`
+	Base64 string `json:"data_base64,omitempty"`
+}
+
+func NewData(b []byte) Data {
+	if utf8.Valid(b) {
+		return Data{Text: string(b)}
+	}
+	return Data{Base64: base64.StdEncoding.EncodeToString(b)}
+}
+
+// Bytes decodes `d`.
+func (d Data) Bytes() ([]byte, error) {
+	if d.Base64 != "" {
+		return base64.StdEncoding.DecodeString(d.Base64)
+	}
+	return []byte(d.Text), nil
+}
+
+const (
+	EventPhaseStarted  = "phase_started"
+	EventStdout        = "stdout"
+	EventStderr        = "stderr"
+	EventDiagnostic    = "diagnostic"
+	EventPhaseFinished = "phase_finished"
+	EventCaseFinished  = "case_finished" //for each test case, in order
+	EventExit          = "exit"          //the last event
+)
+
+const (
+	PhaseCompile = "compile"
+	PhaseRun     = "run"
+)
+
+// Event is a line of the output in `jsonl`. The fields other than `Event` and `Time` depend on the event.
+type Event struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+
+	//phase_started, phase_finished
+	Phase string   `json:"phase,omitempty"`
+	Argv  []string `json:"argv,omitempty"`
+	Dir   string   `json:"cwd,omitempty"`
+
+	//stdout, stderr
+	Data
+
+	//diagnostic
+	Diagnostic *diagnostic.Diagnostic `json:"diagnostic,omitempty"`
+
+	//case_finished
+	Case    string `json:"case,omitempty"`
+	Verdict string `json:"verdict,omitempty"`
+
+	//phase_finished, case_finished, exit
+	ExitStatus     *int    `json:"exit_status,omitempty"`
+	Signal         string  `json:"signal,omitempty"`
+	IsCached       bool    `json:"is_cached,omitempty"` //The compilation is skipped by the build cache.
+	ElapsedSeconds float64 `json:"elapsed_seconds,omitempty"`
+	CPUSeconds     float64 `json:"cpu_seconds,omitempty"`
+	MaxRSS         int64   `json:"max_rss_bytes,omitempty"`
+}
+
+// Emitter writes events to a writer, one per line. It is safe for concurrent use.
+type Emitter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func NewEmitter(w io.Writer) *Emitter {
+	return &Emitter{w: w}
+}
+
+func (e *Emitter) Emit(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	var b, err = json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.w.Write(append(b, '\n'))
+}
+
+// streamWriter emits what's written as `stdout` or `stderr` events.
+type streamWriter struct {
+	e     *Emitter
+	event string
+}
+
+func (w streamWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	w.e.Emit(Event{Event: w.event, Data: NewData(p)})
+	return len(p), nil
+}
+
+func (e *Emitter) Stdout() io.Writer {
+	return streamWriter{e, EventStdout}
+}
+
+func (e *Emitter) Stderr() io.Writer {
+	return streamWriter{e, EventStderr}
+}
+
+func phase(o exec.Option) string {
+	if o.IsCompileMode {
+		return PhaseCompile
+	}
+	return PhaseRun
+}
+
+func (e *Emitter) PhaseStarted(o exec.Option) {
+	e.Emit(Event{Event: EventPhaseStarted, Phase: phase(o), Argv: o.Argv(), Dir: o.WorkingDir()})
+}
+
+func (e *Emitter) PhaseFinished(o exec.Option, r exec.Result, exitStatus int, isCached bool) {
+	e.Emit(Event{
+		Event:          EventPhaseFinished,
+		Phase:          phase(o),
+		ExitStatus:     &exitStatus,
+		Signal:         r.Signal,
+		IsCached:       isCached,
+		ElapsedSeconds: r.Elapsed.Seconds(),
+		CPUSeconds:     r.CPUTime.Seconds(),
+		MaxRSS:         r.MaxRSS,
+	})
+}
+
+// CaseFinished emits the verdict of the test case `name`, such as "AC".
+func (e *Emitter) CaseFinished(name string, verdict string, r exec.Result) {
+	e.Emit(Event{
+		Event:          EventCaseFinished,
+		Case:           name,
+		Verdict:        verdict,
+		ExitStatus:     &r.ExitStatus,
+		Signal:         r.Signal,
+		ElapsedSeconds: r.Elapsed.Seconds(),
+		CPUSeconds:     r.CPUTime.Seconds(),
+		MaxRSS:         r.MaxRSS,
+	})
+}
+
+func (e *Emitter) Diagnostic(d diagnostic.Diagnostic) {
+	e.Emit(Event{Event: EventDiagnostic, Diagnostic: &d})
+}
+
+func (e *Emitter) Exit(exitStatus int) {
+	e.Emit(Event{Event: EventExit, ExitStatus: &exitStatus})
+}
@@ -0,0 +1,80 @@
+package protocol
+
+import "bytes"
+import "encoding/json"
+import "strings"
+import "testing"
+
+import "executer/exec"
+
+func Test_Data(t *testing.T) {
+
+	var tests = []struct {
+		b        []byte
+		isBase64 bool
+	}{
+		{[]byte("text\n"), false},
+		{[]byte{0xff, 0xfe, 'a'}, true},
+		{[]byte{}, false},
+	}
+
+	for _, test := range tests {
+		var d = NewData(test.b)
+		if (d.Base64 != "") != test.isBase64 {
+			t.Fatal(test, d)
+		}
+		var ret, err = d.Bytes()
+		if (err != nil) || !bytes.Equal(ret, test.b) {
+			t.Fatal(test, ret, err)
+		}
+	}
+
+}
+
+func Test_Emitter(t *testing.T) {
+
+	var buf bytes.Buffer
+	var e = NewEmitter(&buf)
+
+	var o = exec.Option{Command: "a.out", Dir: "/w"}
+	e.PhaseStarted(o)
+	e.Stdout().Write([]byte("out"))
+	e.Stderr().Write([]byte{0xff})
+	e.PhaseFinished(o, exec.Result{}, 0, false)
+	e.CaseFinished("1.in", "WA", exec.Result{ExitStatus: 0})
+	e.Exit(0)
+
+	var lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var events = make([]map[string]any, 0)
+	for _, line := range lines {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatal(line, err)
+		}
+		events = append(events, m)
+	}
+
+	var expected = []string{EventPhaseStarted, EventStdout, EventStderr, EventPhaseFinished, EventCaseFinished, EventExit}
+	if len(events) != len(expected) {
+		t.Fatal(lines)
+	}
+	for i, m := range events {
+		if m["event"] != expected[i] {
+			t.Fatal(i, m)
+		}
+	}
+
+	if (events[0]["phase"] != PhaseRun) || (events[0]["cwd"] != "/w") {
+		t.Fatal(events[0])
+	}
+	if (events[1]["data"] != "out") || (events[2]["data_base64"] != "/w==") {
+		t.Fatal(events[1], events[2])
+	}
+	if events[3]["exit_status"] != 0.0 { //present even if zero
+		t.Fatal(events[3])
+	}
+	if (events[4]["case"] != "1.in") || (events[4]["verdict"] != "WA") || (events[4]["exit_status"] != 0.0) {
+		t.Fatal(events[4])
+	}
+
+}
@@ -0,0 +1,103 @@
+package report
+
+import "encoding/json"
+import "os"
+import "path/filepath"
+import "time"
+
+import "executer/diagnostic"
+import "executer/exec"
+import "executer/util"
+
+const (
+	FormatJSON = "json"
+)
+
+var Formats = []string{FormatJSON}
+
+// Command is the record of a command executed.
+type Command struct {
+	Argv           []string  `json:"argv"`
+	Dir            string    `json:"cwd"`
+	IsCompileMode  bool      `json:"is_compile_mode"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	ElapsedSeconds float64   `json:"elapsed_seconds"`
+	CPUSeconds     float64   `json:"cpu_seconds"`
+	ExitStatus     int       `json:"exit_status"`
+	Signal         string    `json:"signal,omitempty"`
+	MaxRSS         int64     `json:"max_rss_bytes"`
+	Case           string    `json:"case,omitempty"`    //the name of the test case which the command ran for
+	Verdict        string    `json:"verdict,omitempty"` //of the test case
+}
+
+// Report is the summary of an invocation of executer.
+type Report struct {
+	Source      string                  `json:"source"`
+	Runner      string                  `json:"runner"`
+	Profile     string                  `json:"profile,omitempty"`
+	Start       time.Time               `json:"start"`
+	End         time.Time               `json:"end"`
+	ExitStatus  int                     `json:"exit_status"`
+	Commands    []Command               `json:"commands"`
+	Diagnostics []diagnostic.Diagnostic `json:"diagnostics"`
+}
+
+// DefaultFile returns the file which the report is written to when no file is specified.
+func DefaultFile() string {
+	return filepath.Join(util.XDGDir("XDG_STATE_HOME", ".local/state"), "report.json")
+}
+
+func New(source string, runner string, profile string) *Report {
+	return &Report{
+		Source:      source,
+		Runner:      runner,
+		Profile:     profile,
+		Start:       time.Now(),
+		Commands:    make([]Command, 0),
+		Diagnostics: make([]diagnostic.Diagnostic, 0),
+	}
+}
+
+// NewCommand returns the record of the command `o` which resulted in `r`.
+func NewCommand(o exec.Option, r exec.Result) Command {
+	return Command{
+		Argv:           o.Argv(),
+		Dir:            o.WorkingDir(),
+		IsCompileMode:  o.IsCompileMode,
+		Start:          r.Start,
+		End:            r.End,
+		ElapsedSeconds: r.Elapsed.Seconds(),
+		CPUSeconds:     r.CPUTime.Seconds(),
+		ExitStatus:     r.ExitStatus,
+		Signal:         r.Signal,
+		MaxRSS:         r.MaxRSS,
+	}
+}
+
+// Add records the command `o` which resulted in `r`.
+func (rep *Report) Add(o exec.Option, r exec.Result) {
+	rep.Commands = append(rep.Commands, NewCommand(o, r))
+}
+
+// AddCase records the run of `o` for the test case `name`, which resulted in `r` and was judged as `verdict`.
+func (rep *Report) AddCase(name string, verdict string, o exec.Option, r exec.Result) {
+	var c = NewCommand(o, r)
+	c.Case = name
+	c.Verdict = verdict
+	rep.Commands = append(rep.Commands, c)
+}
+
+// Write finishes the report with `exitStatus` and writes it to `file` as JSON.
+func (rep *Report) Write(file string, exitStatus int) error {
+	rep.End = time.Now()
+	rep.ExitStatus = exitStatus
+	var b, err = json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(b, '\n'), 0644)
+}
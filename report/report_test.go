@@ -0,0 +1,78 @@
+package report
+
+import "encoding/json"
+import "os"
+import "path/filepath"
+import "strings"
+import "testing"
+import "time"
+
+import "executer/exec"
+
+func Test_Report(t *testing.T) {
+
+	var rep = New("/w/a.cpp", "cpp", "release")
+
+	var start = time.Now()
+	var result = exec.Result{
+		Start:      start,
+		End:        start.Add(1500 * time.Millisecond),
+		Elapsed:    1500 * time.Millisecond,
+		ExitStatus: 1,
+		MaxRSS:     1024,
+	}
+	rep.Add(exec.Option{Command: "g++", Arguments: []string{"a.cpp"}, Dir: "/w", IsCompileMode: true}, exec.Result{Start: start, End: start})
+	rep.AddCase("sample1", "WA", exec.Option{Command: "./a.out", Dir: "/w"}, result)
+
+	var file = filepath.Join(t.TempDir(), "sub", "report.json")
+	if err := rep.Write(file, 1); err != nil {
+		t.Fatal(err)
+	}
+	var b, err = os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("fields", func(t *testing.T) {
+		var m map[string]any
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		if (m["source"] != "/w/a.cpp") || (m["runner"] != "cpp") || (m["profile"] != "release") || (m["exit_status"] != 1.0) {
+			t.Fatal(m)
+		}
+		var commands = m["commands"].([]any)
+		if len(commands) != 2 {
+			t.Fatal(commands)
+		}
+		var compile = commands[0].(map[string]any)
+		if _, ok := compile["case"]; ok || (compile["is_compile_mode"] != true) || (compile["cwd"] != "/w") {
+			t.Fatal(compile)
+		}
+		var run = commands[1].(map[string]any)
+		if (run["case"] != "sample1") || (run["verdict"] != "WA") || (run["elapsed_seconds"] != 1.5) || (run["max_rss_bytes"] != 1024.0) {
+			t.Fatal(run)
+		}
+		if diagnostics, ok := m["diagnostics"].([]any); !ok || (len(diagnostics) != 0) {
+			t.Fatal(m["diagnostics"])
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		var r Report
+		if err := json.Unmarshal(b, &r); err != nil {
+			t.Fatal(err)
+		}
+		if (r.Commands[0].Argv[0] != "g++") || (r.Commands[1].ExitStatus != 1) || r.End.Before(r.Start) {
+			t.Fatal(r)
+		}
+	})
+
+	t.Run("no profile", func(t *testing.T) {
+		var b, _ = json.Marshal(New("a.py", "python", ""))
+		if strings.Contains(string(b), "profile") {
+			t.Fatal(string(b))
+		}
+	})
+
+}
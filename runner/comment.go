@@ -0,0 +1,34 @@
+package runner
+
+// CommentSyntax describes how comments are written in a language.
+// Empty fields mean the language doesn't have that kind of comments.
+type CommentSyntax struct {
+	Line       string //`//`
+	BlockBegin string //`/*`
+	BlockEnd   string //`*/`
+}
+
+var commentSyntaxes = map[string]CommentSyntax{
+	"py":   {Line: "#"},
+	"rb":   {Line: "#", BlockBegin: "=begin", BlockEnd: "=end"},
+	"sh":   {Line: "#"},
+	"gp":   {Line: "#"},
+	"sql":  {Line: "--", BlockBegin: "/*", BlockEnd: "*/"},
+	"bats": {Line: "#"},
+	"awk":  {Line: "#"},
+	"js":   {Line: "//", BlockBegin: "/*", BlockEnd: "*/"},
+	"ts":   {Line: "//", BlockBegin: "/*", BlockEnd: "*/"},
+	"c":    {Line: "//", BlockBegin: "/*", BlockEnd: "*/"},
+	"cpp":  {Line: "//", BlockBegin: "/*", BlockEnd: "*/"},
+	"java": {Line: "//", BlockBegin: "/*", BlockEnd: "*/"},
+	"hs":   {Line: "--", BlockBegin: "{-", BlockEnd: "-}"},
+	"go":   {Line: "//", BlockBegin: "/*", BlockEnd: "*/"},
+	"rs":   {Line: "//", BlockBegin: "/*", BlockEnd: "*/"},
+	"dart": {Line: "//", BlockBegin: "/*", BlockEnd: "*/"},
+}
+
+// Comment returns the comment syntax of the language whose extension is `ext`.
+func Comment(ext string) (CommentSyntax, bool) {
+	var ret, ok = commentSyntaxes[ext]
+	return ret, ok
+}
@@ -0,0 +1,161 @@
+package runner
+
+import "fmt"
+import "regexp"
+import "strings"
+
+import "executer/source"
+import "executer/util"
+
+// block is a definition which may enclose a line, e.g. a test function or a `describe` block.
+type block struct {
+	Name   string
+	Indent int
+	Line   int //1-based
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// enclosingBlocks returns the blocks matched by `re` (whose first group is the name) enclosing the line `n`,
+// from the outermost to the innermost. The nesting is judged by indentation.
+func enclosingBlocks(lines []string, n int, re *regexp.Regexp) []block {
+	var ret = make([]block, 0)
+	if (n < 1) || (n > len(lines)) {
+		return ret
+	}
+	var indent = -1 //indentation of the innermost block found so far (`-1` before it's found)
+	for i := n - 1; i >= 0; i-- {
+		var m = re.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		var b = block{Name: m[1], Indent: indentOf(lines[i]), Line: i + 1}
+		if (indent != -1) && (b.Indent >= indent) {
+			continue //a sibling before the line
+		}
+		ret = append([]block{b}, ret...)
+		indent = b.Indent
+	}
+	return ret
+}
+
+var (
+	goTestRegexp    = regexp.MustCompile(`^func\s+((?:Test|Benchmark|Fuzz|Example)\w*)\s*\(`)
+	goFuncRegexp    = regexp.MustCompile(`^func\s`)
+	rustFnRegexp    = regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?fn\s+(\w+)`)
+	rustTestRegexp  = regexp.MustCompile(`^\s*#\[(?:\w+::)*test\b`)
+	rustModRegexp   = regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?mod\s+(\w+)\s*\{`)
+	hspecRegexp     = regexp.MustCompile(`^\s*(?:describe|context|it|specify)\s+"((?:[^"\\]|\\.)*)"`)
+	dartTestRegexp  = regexp.MustCompile(`^\s*(?:group|test|testWidgets)\(\s*['"]((?:[^'"\\]|\\.)*)['"]`)
+	jestBlockRegexp = regexp.MustCompile(`^\s*(?:describe|it|test)(?:\.\w+)?\(\s*['"` + "`" + `]((?:[^'"` + "`" + `\\]|\\.)*)['"` + "`" + `]`)
+)
+
+// goTest returns the name of the test function enclosing the line `n`.
+func goTest(lines []string, n int) (string, bool) {
+	for i := n - 1; (i >= 0) && (i < len(lines)); i-- {
+		if m := goTestRegexp.FindStringSubmatch(lines[i]); m != nil {
+			return m[1], true
+		}
+		if goFuncRegexp.MatchString(lines[i]) {
+			return "", false //in a non-test function
+		}
+	}
+	return "", false
+}
+
+// rustTest returns the function with `#[test]` (or e.g. `#[tokio::test]`) enclosing the line `n`.
+func rustTest(lines []string, n int) (block, bool) {
+	var l = enclosingBlocks(lines, n, rustFnRegexp)
+	if len(l) == 0 {
+		return block{}, false
+	}
+	var b = l[len(l)-1]
+	//The attributes and the comments above the function are searched.
+	for i := b.Line - 2; i >= 0; i-- {
+		var line = strings.TrimSpace(lines[i])
+		if rustTestRegexp.MatchString(line) {
+			return b, true
+		}
+		if !(strings.HasPrefix(line, "#[") || strings.HasPrefix(line, "//")) {
+			break
+		}
+	}
+	return block{}, false
+}
+
+func names(l []block) []string {
+	var ret = make([]string, 0, len(l))
+	for _, b := range l {
+		ret = append(ret, b.Name)
+	}
+	return ret
+}
+
+// testFilter returns the arguments which make the test command of `s` run only the test enclosing the line `n`.
+func testFilter(s source.Source, n int) ([]string, bool) {
+
+	var lines = util.ReadFileUnchecked(s.Path)
+
+	switch s.Ext {
+
+	case "go":
+		var name, ok = goTest(lines, n)
+		if !ok {
+			return nil, false
+		}
+		var pattern = fmt.Sprintf("^%v$", name)
+		if strings.HasPrefix(name, "Benchmark") {
+			return []string{"-run", "^$", "-bench", pattern}, true
+		}
+		return []string{"-run", pattern}, true
+
+	case "rs":
+		//The filter of `cargo test` is a substring of the path, e.g. `tests::a`, which is matched exactly with `--exact`.
+		var test, ok = rustTest(lines, n)
+		if !ok {
+			return nil, false
+		}
+		var l = make([]string, 0)
+		for _, b := range enclosingBlocks(lines, n, rustModRegexp) {
+			if b.Indent < test.Indent {
+				l = append(l, b.Name)
+			}
+		}
+		if root, _, _, err := cargoPackage(s.Dir); err == nil {
+			if m := rustModulePath(root, s.Path); m != "" {
+				l = append([]string{m}, l...)
+			}
+		}
+		return []string{strings.Join(append(l, test.Name), "::"), "--", "--exact"}, true
+
+	case "hs":
+		//hspec matches `--match` against the path like `describe/it`.
+		var l = enclosingBlocks(lines, n, hspecRegexp)
+		if len(l) == 0 {
+			return nil, false
+		}
+		return []string{fmt.Sprintf("--test-option=--match=/%v/", strings.Join(names(l), "/"))}, true
+
+	case "dart":
+		//The full name of a test is the descriptions of the groups and the test joined by spaces.
+		var l = enclosingBlocks(lines, n, dartTestRegexp)
+		if len(l) == 0 {
+			return nil, false
+		}
+		return []string{"--name", regexp.QuoteMeta(strings.Join(names(l), " "))}, true
+
+	case "ts":
+		//The same applies to Jest.
+		var l = enclosingBlocks(lines, n, jestBlockRegexp)
+		if len(l) == 0 {
+			return nil, false
+		}
+		return []string{"--", "-t", regexp.QuoteMeta(strings.Join(names(l), " "))}, true
+
+	}
+
+	return nil, false
+
+}
@@ -0,0 +1,137 @@
+package runner
+
+import "os"
+import "path/filepath"
+import "strings"
+import "testing"
+
+import "golang.org/x/exp/slices"
+
+import "executer/source"
+
+func Test_testFilter(t *testing.T) {
+
+	var check = func(t *testing.T, base string, contents string, n int, expected []string) {
+		var file = filepath.Join(t.TempDir(), base)
+		if err := os.WriteFile(file, []byte(strings.TrimPrefix(contents, "\n")), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var l, ok = testFilter(source.New(file), n)
+		if (ok != (expected != nil)) || !slices.Equal(l, expected) {
+			t.Fatal(l, ok)
+		}
+	}
+
+	var goSource = `
+package main
+
+func helper() {
+}
+
+func TestA(t *testing.T) {
+	helper()
+}
+
+func BenchmarkB(b *testing.B) {
+}
+`
+
+	t.Run("go", func(t *testing.T) {
+		check(t, "a_test.go", goSource, 7, []string{"-run", "^TestA$"})
+		check(t, "a_test.go", goSource, 10, []string{"-run", "^$", "-bench", "^BenchmarkB$"})
+		check(t, "a_test.go", goSource, 4, nil)
+	})
+
+	var rustSource = `
+fn helper() {
+}
+
+#[cfg(test)]
+mod tests {
+    #[test]
+    // comment
+    fn a() {
+        helper();
+    }
+
+    #[tokio::test]
+    async fn b() {
+    }
+}
+`
+
+	t.Run("rs", func(t *testing.T) {
+		check(t, "lib.rs", rustSource, 9, []string{"tests::a", "--", "--exact"})
+		check(t, "lib.rs", rustSource, 13, []string{"tests::b", "--", "--exact"})
+		check(t, "lib.rs", rustSource, 2, nil)
+	})
+
+	t.Run("rs in a module file", func(t *testing.T) {
+		var root = t.TempDir()
+		var file = filepath.Join(root, "src", "foo", "bar.rs")
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "Cargo.toml"), []byte("[package]\nname = \"p\"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("mod helpers {\n}\n\n#[test]\nfn c() {\n}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var l, ok = testFilter(source.New(file), 5)
+		if !ok || !slices.Equal(l, []string{"foo::bar::c", "--", "--exact"}) {
+			t.Fatal(l, ok)
+		}
+	})
+
+	var hspecSource = `
+spec = do
+  describe "add" $ do
+    it "adds 1" $ do
+      add 1 1 ` + "`shouldBe`" + ` 2
+    it "adds 2" $ do
+      add 1 2 ` + "`shouldBe`" + ` 3
+`
+
+	t.Run("hs", func(t *testing.T) {
+		check(t, "Spec.hs", hspecSource, 6, []string{"--test-option=--match=/add/adds 2/"})
+		check(t, "Spec.hs", hspecSource, 2, []string{"--test-option=--match=/add/"})
+		check(t, "Spec.hs", hspecSource, 1, nil)
+	})
+
+	var dartSource = `
+void main() {
+  group('add', () {
+    test('adds (1)', () {
+      expect(add(1, 1), 2);
+    });
+  });
+}
+`
+
+	t.Run("dart", func(t *testing.T) {
+		check(t, "a_test.dart", dartSource, 4, []string{"--name", `add adds \(1\)`})
+		check(t, "a_test.dart", dartSource, 1, nil)
+	})
+
+	var jestSource = `
+describe("add", () => {
+  it("adds 1", () => {
+    expect(add(1, 1)).toBe(2);
+  });
+  it.skip("adds 2", () => {
+    expect(add(1, 2)).toBe(3);
+  });
+});
+`
+
+	t.Run("ts", func(t *testing.T) {
+		check(t, "a.test.ts", jestSource, 3, []string{"--", "-t", "add adds 1"})
+		check(t, "a.test.ts", jestSource, 7, []string{"--", "-t", "add adds 2"})
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		check(t, "a.py", "def test_a():\n    pass\n", 2, nil)
+	})
+
+}
@@ -0,0 +1,115 @@
+package runner
+
+import "errors"
+import "fmt"
+import "os"
+import "path/filepath"
+import "regexp"
+import "strconv"
+import "strings"
+import "time"
+
+import "executer/option"
+import "executer/util"
+
+// goModuleRoot returns the directory of the `go.mod` nearest to `dir`, searching the ancestors.
+func goModuleRoot(dir string) (string, bool) {
+	for {
+		if util.IsFile(filepath.Join(dir, "go.mod")) {
+			return dir, true
+		}
+		var parent = filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// goPackage returns the root of the module containing the directory `dir`,
+// and the package in `dir` relative to the root (e.g. `./internal/util`, or `.` for the root package).
+// The commands should be run in the root, where `go` also finds `go.work` if the module is in a workspace.
+func goPackage(dir string) (string, string, bool) {
+	var root, ok = goModuleRoot(dir)
+	if !ok {
+		return "", "", false
+	}
+	var rel, err = filepath.Rel(root, dir)
+	if err != nil {
+		return "", "", false
+	}
+	if rel == "." {
+		return root, ".", true
+	}
+	return root, "./" + filepath.ToSlash(rel), true
+}
+
+var goModuleRegexp = regexp.MustCompile(`^module\s+(\S+)$`)
+
+// goModulePath returns the module path declared in the `go.mod` in `root`.
+// The comments and the quoted form (`module "example.com/a"`) are handled as `go` does.
+func goModulePath(root string) (string, error) {
+	var b, err = os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if i := strings.Index(line, "//"); i != -1 {
+			line = line[:i]
+		}
+		var m = goModuleRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		if strings.HasPrefix(m[1], `"`) || strings.HasPrefix(m[1], "`") {
+			return strconv.Unquote(m[1])
+		}
+		return m[1], nil
+	}
+	return "", errors.New("no module directive in `go.mod`")
+}
+
+var goMajorVersionRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+// goBinaryName returns the name of the executable which `go build` creates for the package `importPath`.
+// As in `go build`, the major version suffix is skipped (e.g. `tool` for `example.com/tool/v2`).
+func goBinaryName(importPath string) string {
+	var l = strings.Split(importPath, "/")
+	if (len(l) >= 2) && goMajorVersionRegexp.MatchString(l[len(l)-1]) {
+		return l[len(l)-2]
+	}
+	return l[len(l)-1]
+}
+
+var goCommandRegexp = regexp.MustCompile(`^\./cmd/[^/]+$`)
+
+// isGoCommand reports whether the package `packagePath` (relative to the module root) is a main package to run,
+// i.e. the root package or `cmd/<name>`.
+func isGoCommand(packagePath string) bool {
+	return (packagePath == ".") || goCommandRegexp.MatchString(packagePath)
+}
+
+const goDefaultFuzzTime = 30 * time.Second
+
+// goTestFlags returns the flags of `go test` for the modes specified in `o` (e.g. `--bench`).
+// The coverage profile is written to `profile` if it's non-empty.
+func goTestFlags(o option.Options, profile string) []string {
+	var ret = make([]string, 0)
+	if o.ShouldDetectRace {
+		ret = append(ret, "-race")
+	}
+	if o.Bench != "" {
+		ret = append(ret, "-run", "^$", "-bench", o.Bench, "-benchmem")
+	}
+	if o.Fuzz != "" {
+		var fuzzTime = o.FuzzTime
+		if fuzzTime == 0 {
+			fuzzTime = goDefaultFuzzTime
+		}
+		ret = append(ret, "-run", "^$", "-fuzz", fmt.Sprintf("^%v$", o.Fuzz), "-fuzztime", fuzzTime.String())
+	}
+	if profile != "" {
+		ret = append(ret, "-coverprofile", profile)
+	}
+	return ret
+}
@@ -0,0 +1,143 @@
+package runner
+
+import "os"
+import "path/filepath"
+import "testing"
+import "time"
+
+import "golang.org/x/exp/slices"
+
+import "executer/option"
+
+func Test_goPackage(t *testing.T) {
+
+	var dir = t.TempDir()
+	for _, d := range []string{"internal/util", "mod/sub"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"go.mod", "mod/go.mod"} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte("module example.com/a\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var testCases = []struct {
+		dir         string
+		root        string
+		packagePath string
+	}{
+		{".", ".", "."},
+		{"internal/util", ".", "./internal/util"},
+		{"mod", "mod", "."}, //nested module
+		{"mod/sub", "mod", "./sub"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.dir, func(t *testing.T) {
+			var root, packagePath, ok = goPackage(filepath.Join(dir, tc.dir))
+			if !ok || (root != filepath.Join(dir, tc.root)) || (packagePath != tc.packagePath) {
+				t.Fatal(root, packagePath, ok)
+			}
+		})
+	}
+
+	t.Run("outside of modules", func(t *testing.T) {
+		if _, _, ok := goPackage(filepath.Dir(dir)); ok {
+			t.FailNow()
+		}
+	})
+
+}
+
+func Test_goModulePath(t *testing.T) {
+
+	var testCases = []struct {
+		name     string
+		contents string
+		expected string
+	}{
+		{"normal", "module example.com/a\n\ngo 1.21\n", "example.com/a"},
+		{"comments", "// Deprecated: use example.com/b.\nmodule example.com/a // comment\n", "example.com/a"},
+		{"quoted", "module \"example.com/a\"\n", "example.com/a"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var dir = t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(tc.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var ret, err = goModulePath(dir)
+			if (err != nil) || (ret != tc.expected) {
+				t.Fatal(ret, err)
+			}
+		})
+	}
+
+	t.Run("no module directive", func(t *testing.T) {
+		var dir = t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("go 1.21\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := goModulePath(dir); err == nil {
+			t.FailNow()
+		}
+	})
+
+}
+
+func Test_goBinaryName(t *testing.T) {
+	var testCases = map[string]string{
+		"a":                          "a",
+		"github.com/org/tool":        "tool",
+		"github.com/org/tool/v2":     "tool",
+		"github.com/org/tool/cmd/cl": "cl",
+	}
+	for importPath, expected := range testCases {
+		if ret := goBinaryName(importPath); ret != expected {
+			t.Fatal(importPath, ret)
+		}
+	}
+}
+
+func Test_isGoCommand(t *testing.T) {
+	var testCases = map[string]bool{
+		".":              true,
+		"./cmd/tool":     true,
+		"./cmd/tool/sub": false,
+		"./internal/cmd": false,
+	}
+	for packagePath, expected := range testCases {
+		if isGoCommand(packagePath) != expected {
+			t.Fatal(packagePath)
+		}
+	}
+}
+
+func Test_goTestFlags(t *testing.T) {
+
+	var testCases = []struct {
+		name     string
+		o        option.Options
+		profile  string
+		expected []string
+	}{
+		{"none", option.Options{}, "", []string{}},
+		{"race", option.Options{ShouldDetectRace: true}, "", []string{"-race"}},
+		{"bench", option.Options{Bench: "."}, "", []string{"-run", "^$", "-bench", ".", "-benchmem"}},
+		{"fuzz", option.Options{Fuzz: "FuzzA"}, "", []string{"-run", "^$", "-fuzz", "^FuzzA$", "-fuzztime", "30s"}},
+		{"fuzz time", option.Options{Fuzz: "FuzzA", FuzzTime: time.Minute}, "", []string{"-run", "^$", "-fuzz", "^FuzzA$", "-fuzztime", "1m0s"}},
+		{"cover", option.Options{ShouldCover: true}, "cover.out", []string{"-coverprofile", "cover.out"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if ret := goTestFlags(tc.o, tc.profile); !slices.Equal(ret, tc.expected) {
+				t.Fatal(ret)
+			}
+		})
+	}
+
+}
@@ -0,0 +1,549 @@
+package runner
+
+import "errors"
+import "fmt"
+import "path"
+import "path/filepath"
+import "regexp"
+import "runtime"
+import "strings"
+
+import "executer/cache"
+import "executer/exec"
+import "executer/option"
+import "executer/source"
+import "executer/util"
+
+// Runner is the sequence of commands which compiles and executes a source.
+type Runner struct {
+	Name  string //e.g. `cargo test`
+	Steps []exec.Option
+}
+
+// Program splits the steps into the compilation steps and the final execution step.
+// This is for the callers which run the program by themselves (e.g. with their own stdin).
+func (r Runner) Program() ([]exec.Option, exec.Option, error) {
+	if (len(r.Steps) == 0) || r.Steps[len(r.Steps)-1].IsCompileMode {
+		return nil, exec.Option{}, errors.New("no execution step")
+	}
+	var build = r.Steps[:len(r.Steps)-1]
+	for _, o := range build {
+		if !o.IsCompileMode {
+			return nil, exec.Option{}, errors.New("more than one execution steps")
+		}
+	}
+	return build, r.Steps[len(r.Steps)-1], nil
+}
+
+// artifact returns the path to the executable compiled from the unit file `s`.
+// It is placed in the cache directory of `s` unless `isInPlace`, in which case it is next to `s`.
+func artifact(s source.Source, isInPlace bool) string {
+	if isInPlace {
+		return s.PathWoExt + ".out"
+	}
+	return filepath.Join(cache.Dir(s.Path), s.Name+".out")
+}
+
+// isTestMode is defined here as `option` refers to the argument in `Resolve`.
+func isTestMode(o option.Options) bool {
+	return o.Mode == option.ModeTest
+}
+
+// Resolve decides the commands to compile and execute `option.Source`.
+// `base` provides the fields common to all the commands (e.g. `IsDebugMode`).
+func Resolve(option option.Options, base exec.Option) (Runner, error) {
+
+	var ret = Runner{}
+
+	var createExecOption = func(command string, isCompileMode bool) exec.Option {
+		var o = base
+		o.IsCompileMode = isCompileMode
+		o.Command = command
+		o.CompileOptions = option.CompileArgs
+		o.Arguments = []string{option.Source.Path}
+		o.ExecOptions = option.ExecArgs
+		o.ShouldMeasureTime = option.ShouldMeasureTime
+		return o
+	}
+
+	var add = func(o exec.Option) {
+		ret.Steps = append(ret.Steps, o)
+	}
+
+	var s = option.Source
+
+	//filter returns the arguments of the test command to run only the test at `option.Line`, or nil to run all.
+	var isFiltered = false //whether the runner supports `--line`
+	var filter = func() []string {
+		isFiltered = true
+		if option.Line == 0 {
+			return nil
+		}
+		var l, ok = testFilter(s, option.Line)
+		if !ok {
+			fmt.Fprintf(base.ErrWriter(), "\u001B[093mNo test found at line %v. All the tests are run.\u001B[0m\n", option.Line)
+			return nil
+		}
+		return l
+	}
+
+	var isGoTestMode = (option.Bench != "") || (option.Fuzz != "") || option.ShouldCover
+	if (s.Ext != "go") && (isGoTestMode || option.ShouldDetectRace) {
+		return ret, errors.New("`--race`, `--bench`, `--fuzz` and `--cover` are only for Go")
+	}
+
+	switch s.Ext {
+
+	case "py":
+		{
+			ret.Name = "python"
+			var o exec.Option
+			if runtime.GOOS == "darwin" {
+				o = createExecOption("python3.11", false)
+			} else {
+				o = createExecOption("python3", false)
+			}
+			add(o)
+		}
+
+	case "rb":
+		{
+			ret.Name = "ruby"
+			var o exec.Option = createExecOption("ruby", false)
+			add(o)
+		}
+
+	case "sh":
+		{
+			ret.Name = "bash"
+			var o = createExecOption("bash", false)
+			add(o)
+		}
+
+	case "gp":
+		{
+			ret.Name = "gnuplot"
+			var o = createExecOption("gnuplot", false)
+			o.CompileOptions = append([]string{"--persist"}, o.CompileOptions...)
+			add(o)
+		}
+
+	case "sql":
+		{
+			ret.Name = "sqlite3"
+			var o = createExecOption("sqlite3", false)
+			o.CompileOptions = append(
+				append(
+					[]string{":memory:", "-init", "", "-batch"},
+					o.CompileOptions...,
+				),
+				fmt.Sprintf(".read %v", s.Path),
+			)
+			o.Arguments = nil
+			add(o)
+		}
+
+	case "bats": //testing framework for Bash
+		{
+			ret.Name = "bats"
+			var o = createExecOption("bats", false)
+			o.CompileOptions = append([]string{"--print-output-on-failure", "--show-output-of-passing-tests"}, o.CompileOptions...)
+			add(o)
+		}
+
+	case "awk":
+		{
+			var prog = strings.Join(util.ReadFileUnchecked(s.Path), "\n")
+			//We require there is a `BEGIN` block to avoid stdin's begin read.
+			if !strings.Contains(prog, "BEGIN {") {
+				return ret, errors.New("the input doesn't include `BEGIN { ... }` block")
+			}
+			ret.Name = "awk"
+			var o = createExecOption("awk", false)
+			o.Arguments = []string{prog}
+			add(o)
+		}
+
+	case "js":
+		{
+			ret.Name = "node"
+			var o = createExecOption("node", false)
+			add(o)
+		}
+
+	case "ts":
+		{
+			if strings.HasSuffix(s.Original, "test.ts") {
+				ret.Name = "npm test"
+				var o = createExecOption("npm", false)
+				o.CompileOptions = append(append([]string{"test"}, o.CompileOptions...), filter()...)
+				o.Arguments = nil
+				add(o)
+			} else {
+				ret.Name = "tsc"
+				if !option.IsOnlyExecuteMode {
+					var o = createExecOption("tsc", true)
+					o.CompileOptions = append([]string{"--build"}, option.CompileArgs...)
+					o.Arguments = nil
+					o.ExecOptions = nil
+					add(o)
+				}
+				if !option.IsOnlyCompileMode {
+					var o = createExecOption("node", false)
+					o.CompileOptions = nil
+					o.Arguments = []string{fmt.Sprintf("%v/target/%v.js", s.Dir, s.Name)}
+					add(o)
+				}
+			}
+		}
+
+	case "c", "cpp":
+		{
+			ret.Name = s.Ext
+			var output = artifact(s, option.ShouldPutArtifactsInPlace)
+			if !option.IsOnlyExecuteMode {
+				var o = func() exec.Option {
+
+					if runtime.GOOS == "darwin" {
+						if s.Ext == "c" {
+							return createExecOption("gcc-13", true)
+						}
+						return createExecOption("g++-13", true)
+					}
+
+					if s.Ext == "c" {
+						return createExecOption("gcc", true)
+					}
+					return createExecOption("g++", true)
+
+				}()
+				o.CompileOptions = append([]string{"-fdiagnostics-color=always", "-Wfatal-errors", "-o", output}, option.CompileArgs...)
+				//The headers included are listed in the depfile so that the build cache notices their modification.
+				//It's always in the cache directory, as it's for the cache and not an artifact.
+				o.Depfile = filepath.Join(cache.Dir(s.Path), s.Name+".d")
+				o.CompileOptions = append(o.CompileOptions, "-MMD", "-MF", o.Depfile)
+				if s.Ext == "c" {
+					o.CompileOptions = append(o.CompileOptions, "-l", "m")
+				}
+				o.ExecOptions = nil
+				o.Artifact = output
+				add(o)
+			}
+			if !option.IsOnlyCompileMode {
+				var o = createExecOption(output, false)
+				o.CompileOptions = nil
+				o.Arguments = nil
+				add(o)
+			}
+		}
+
+	case "java":
+		{
+			if util.IsFile(filepath.Join(base.WorkingDir(), "settings.gradle")) { //project
+				ret.Name = "gradle"
+				if !option.IsOnlyExecuteMode {
+					var o = createExecOption("gradle", true)
+					o.CompileOptions = append([]string{"build", "--quiet", "--console", "plain"}, option.CompileArgs...)
+					o.Arguments = nil
+					o.ExecOptions = nil
+					add(o)
+				}
+				if !option.IsOnlyCompileMode {
+					var fqcn = func() string {
+						var packageName = regexp.MustCompile(`package ([^;]+);`).FindStringSubmatch(
+							strings.Join(util.ReadFileUnchecked(s.Path), "\n"),
+						)[1]
+						return fmt.Sprintf("%v.%v", packageName, s.Name)
+					}()
+					var o = createExecOption("java", false)
+					o.CompileOptions = []string{
+						"-enableassertions",
+						"--class-path",
+						"./app/build/classes/java/main:./app/build/classes/java/test/",
+					}
+					o.Arguments = []string{fqcn}
+					add(o)
+				}
+			} else { //non-project (unit file)
+				ret.Name = "javac"
+				if !option.IsOnlyExecuteMode {
+					var o = createExecOption("javac", true)
+					o.ExecOptions = nil
+					add(o)
+				}
+				if !option.IsOnlyCompileMode {
+					var o = createExecOption("java", false)
+					o.CompileOptions = []string{"-enableassertions"}
+					o.Arguments = []string{s.Name}
+					add(o)
+				}
+			}
+		}
+
+	case "hs":
+		{
+			var cabalFiles, _ = filepath.Glob(filepath.Join(base.WorkingDir(), "*.cabal"))
+			if cabalFiles != nil { //project
+				if strings.Contains(s.Path, "/test/") { //test files
+					ret.Name = "cabal test"
+					var o = createExecOption("cabal", true)
+					o.CompileOptions = append([]string{"test", "-v0", "--test-show-details=streaming", "--test-option=--color", "--ghc-options=-Wall"}, filter()...)
+					o.CompileOptions = append(o.CompileOptions, option.CompileArgs...)
+					o.Arguments = nil
+					o.ExecOptions = nil
+					add(o)
+				} else {
+					ret.Name = "cabal"
+					var packageName = regexp.MustCompile(`\.cabal$`).ReplaceAllString(filepath.Base(cabalFiles[0]), "")
+					if !option.IsOnlyExecuteMode {
+						var o = createExecOption("cabal", true)
+						o.CompileOptions = append([]string{"build", "-v0", "--ghc-options=-Wall"}, option.CompileArgs...)
+						o.Arguments = nil
+						o.ExecOptions = nil
+						add(o)
+					}
+					if !option.IsOnlyCompileMode && (s.Base == "Main.hs") {
+						var o = createExecOption("cabal", false)
+						o.CompileOptions = []string{"exec", packageName}
+						o.Arguments = nil
+						add(o)
+					}
+				}
+			} else {
+				ret.Name = "ghc"
+				var output = artifact(s, option.ShouldPutArtifactsInPlace)
+				if !option.IsOnlyExecuteMode {
+					var o = createExecOption("ghc", true)
+					o.CompileOptions = append([]string{"-v0", "-Wall", "-Wno-type-defaults", "-o", output}, option.CompileArgs...)
+					if !option.ShouldPutArtifactsInPlace {
+						o.CompileOptions = append(o.CompileOptions, "-outputdir", filepath.Dir(output)) //for `.hi` and `.o`
+					}
+					o.ExecOptions = nil
+					o.Artifact = output
+					add(o)
+				}
+				if !option.IsOnlyCompileMode {
+					var o = createExecOption(output, false)
+					o.CompileOptions = nil
+					o.Arguments = nil
+					add(o)
+				}
+			}
+		}
+
+	case "go":
+		{
+			//The package is the directory of the file in the module containing it, which may differ from the current directory.
+			var root, packagePath, isProject = goPackage(s.Dir)
+			//The benchmarks, the fuzzing and the coverage are for the package, so the file needn't be a test file.
+			if strings.HasSuffix(s.Base, "_test.go") || isGoTestMode { //test files
+				ret.Name = "go test"
+				if !isProject {
+					root, packagePath = s.Dir, "." //GOPATH mode, or an error reported by `go test`
+				}
+				var profile = ""
+				if option.ShouldCover {
+					profile = filepath.Join(cache.Dir(filepath.Join(root, packagePath)), "cover.out")
+					if err := cache.Record(filepath.Join(root, packagePath), profile); err != nil {
+						return ret, fmt.Errorf("failed to create the directory for the coverage profile: %w", err)
+					}
+				}
+				var o = createExecOption("go", true)
+				o.CompileOptions = append([]string{"test", "--count=1", "-v", packagePath}, filter()...)
+				o.CompileOptions = append(o.CompileOptions, goTestFlags(option, profile)...)
+				o.CompileOptions = append(o.CompileOptions, option.CompileArgs...)
+				o.Arguments = nil
+				o.ExecOptions = nil
+				o.Dir = root
+				add(o)
+				//`go tool cover` finds the sources by the import paths in the profile, so it's run in the module as well.
+				if profile != "" {
+					var o = createExecOption("go", false)
+					o.CompileOptions = []string{"tool", "cover", "-func", profile}
+					o.Arguments = nil
+					o.ExecOptions = nil
+					o.Dir = root
+					add(o)
+					if option.CoverHTML != "" {
+						var o = createExecOption("go", false)
+						o.CompileOptions = []string{"tool", "cover", "-html", profile, "-o", option.CoverHTML}
+						o.Arguments = nil
+						o.ExecOptions = nil
+						o.Dir = root
+						add(o)
+					}
+				}
+			} else { //normal files
+				if isProject {
+					ret.Name = "go build"
+					//The main package at the root (`main.go`) or in `cmd/<name>/` is built into the cache directory and run.
+					var isRun = !option.IsOnlyCompileMode && isGoCommand(packagePath) && ((packagePath != ".") || (s.Base == "main.go"))
+					var output = ""
+					if isRun {
+						var modulePath, err = goModulePath(root)
+						if err != nil {
+							return ret, fmt.Errorf("failed to parse `go.mod`: %w", err)
+						}
+						var name = goBinaryName(path.Join(modulePath, packagePath))
+						output = filepath.Join(cache.Dir(filepath.Join(root, packagePath)), name)
+						if err := cache.Record(filepath.Join(root, packagePath), output); err != nil {
+							return ret, fmt.Errorf("failed to create the directory for the executable: %w", err)
+						}
+					}
+					if !option.IsOnlyExecuteMode {
+						var o = createExecOption("go", true)
+						o.CompileOptions = []string{"build"}
+						if option.ShouldDetectRace {
+							o.CompileOptions = append(o.CompileOptions, "-race")
+						}
+						if output != "" {
+							o.CompileOptions = append(o.CompileOptions, "-o", output)
+						}
+						o.CompileOptions = append(append(o.CompileOptions, option.CompileArgs...), packagePath)
+						o.Arguments = nil
+						o.ExecOptions = nil
+						o.Dir = root
+						add(o)
+					}
+					if isRun {
+						var o = createExecOption(output, false)
+						o.CompileOptions = nil
+						o.Arguments = nil
+						add(o)
+					}
+				} else { //non-project (unit file)
+					ret.Name = "go build (unit file)"
+					var output = artifact(s, option.ShouldPutArtifactsInPlace)
+					if !option.IsOnlyExecuteMode {
+						var o = createExecOption("go", true)
+						o.CompileOptions = []string{"build", "-o", output}
+						if option.ShouldDetectRace {
+							o.CompileOptions = append(o.CompileOptions, "-race")
+						}
+						o.CompileOptions = append(o.CompileOptions, option.CompileArgs...)
+						o.ExecOptions = nil
+						o.Artifact = output
+						add(o)
+					}
+					if !option.IsOnlyCompileMode {
+						var o = createExecOption(output, false)
+						o.CompileOptions = nil
+						o.Arguments = nil
+						add(o)
+					}
+				}
+			}
+		}
+
+	case "rs":
+		{
+			var root, packageName, workspace, err = cargoPackage(s.Dir)
+			if err != nil {
+				return ret, err
+			}
+			//Cargo runs in the workspace, where the package is selected with `-p`, and the target with e.g. `--bin`.
+			var selection = make([]string, 0)
+			if workspace != root {
+				selection = append(selection, "-p", packageName)
+			}
+			var kind, name = cargoTarget(root, packageName, s.Path)
+			if kind != "" {
+				selection = append(selection, "--"+kind, name)
+			}
+			//With `--line`, the test at the line is run even if it's in a module.
+			var testArgs = filter()
+			if (kind == "test") || (testArgs != nil) || ((kind != "") && isTestMode(option)) {
+				ret.Name = "cargo test"
+				var o = createExecOption("cargo", true)
+				//The filter is put last as it's followed by the arguments of the test binary.
+				o.CompileOptions = append(append(append([]string{"test"}, selection...), option.CompileArgs...), testArgs...)
+				o.Arguments = nil
+				o.ExecOptions = nil
+				o.Dir = workspace
+				add(o)
+			} else if kind != "" { //binaries and examples
+				ret.Name = "cargo build"
+				//The executable built is linked to `output` as told by Cargo, and executed directly.
+				var output = artifact(s, option.ShouldPutArtifactsInPlace)
+				if !option.ShouldPutArtifactsInPlace {
+					if err := cache.Record(s.Path, output); err != nil {
+						return ret, fmt.Errorf("failed to create the directory for the executable: %w", err)
+					}
+				}
+				if !option.IsOnlyExecuteMode {
+					var o = createExecOption("cargo", true)
+					o.CompileOptions = append(append([]string{"build", "--message-format=json-render-diagnostics"}, selection...), option.CompileArgs...)
+					o.Arguments = nil
+					o.ExecOptions = nil
+					o.Dir = workspace
+					o.MessageWriter = &cargoArtifactLinker{name: name, link: output, errWriter: base.ErrWriter()}
+					add(o)
+				}
+				if !option.IsOnlyCompileMode {
+					var o = createExecOption(output, false)
+					o.CompileOptions = nil
+					o.Arguments = nil
+					add(o)
+				}
+			} else {
+				ret.Name = "cargo check"
+				var o = createExecOption("cargo", true)
+				o.CompileOptions = append(append([]string{"check", "--quiet"}, selection...), option.CompileArgs...)
+				o.Arguments = nil
+				o.ExecOptions = nil
+				o.Dir = workspace
+				add(o)
+			}
+		}
+
+	case "dart":
+		{
+			if strings.HasSuffix(s.Base, "_test.dart") { //test files
+				ret.Name = "dart test"
+				var o = createExecOption("dart", true)
+				o.CompileOptions = append(append([]string{"test"}, filter()...), option.CompileArgs...)
+				o.Arguments = nil
+				o.ExecOptions = nil
+				add(o)
+			} else { //normal files
+				if util.IsFile(filepath.Join(base.WorkingDir(), "pubspec.yaml")) { //project
+					ret.Name = "dart run"
+					var o = createExecOption("dart", true)
+					o.CompileOptions = append([]string{"run", "--enable-asserts"}, option.CompileArgs...)
+					o.Arguments = nil
+					o.ExecOptions = nil
+					add(o)
+				} else { //non-project (unit file)
+					ret.Name = "dart compile"
+					var output = artifact(s, option.ShouldPutArtifactsInPlace)
+					if !option.IsOnlyExecuteMode {
+						var o = createExecOption("dart", true)
+						o.CompileOptions = append([]string{"compile", "exe", "--verbosity", "warning", "-o", output}, option.CompileArgs...)
+						o.ExecOptions = nil
+						o.Artifact = output
+						add(o)
+					}
+					if !option.IsOnlyCompileMode {
+						var o = createExecOption(output, false)
+						o.CompileOptions = nil
+						o.Arguments = nil
+						add(o)
+					}
+				}
+			}
+		}
+
+	default:
+		{
+			return ret, fmt.Errorf("unsupported file type: [ %v ]", s.Ext)
+		}
+
+	}
+
+	if (option.Line != 0) && !isFiltered {
+		fmt.Fprintf(base.ErrWriter(), "\u001B[093m`--line` is ignored as `%v` doesn't run tests.\u001B[0m\n", ret.Name)
+	}
+
+	return ret, nil
+
+}
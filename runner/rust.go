@@ -0,0 +1,194 @@
+package runner
+
+import "bytes"
+import "encoding/json"
+import "errors"
+import "fmt"
+import "io"
+import "os"
+import "path/filepath"
+import "regexp"
+import "strings"
+
+import "golang.org/x/exp/slices"
+
+import "executer/util"
+
+// cargoManifest is the part of `Cargo.toml` which the runner needs.
+type cargoManifest struct {
+	PackageName string //empty for a virtual manifest
+	IsWorkspace bool
+}
+
+var (
+	tomlTableRegexp   = regexp.MustCompile(`^\[\s*([^\[\]]+?)\s*\]`)
+	cargoNameRegexp   = regexp.MustCompile(`^name\s*=\s*"([^"]+)"`)
+	cargoTargetRegexp = regexp.MustCompile(`^(src/bin|examples|tests)/([^/]+?)(?:\.rs|/main\.rs)$`)
+)
+
+// parseCargoManifest reads the name of the package and whether it's the root of a workspace from `file`.
+func parseCargoManifest(file string) (cargoManifest, error) {
+	var ret = cargoManifest{}
+	var b, err = os.ReadFile(file)
+	if err != nil {
+		return ret, err
+	}
+	var table = ""
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if m := tomlTableRegexp.FindStringSubmatch(line); m != nil {
+			table = m[1]
+			if table == "workspace" {
+				ret.IsWorkspace = true
+			}
+			continue
+		}
+		if m := cargoNameRegexp.FindStringSubmatch(line); (m != nil) && (table == "package") {
+			ret.PackageName = m[1]
+		}
+	}
+	return ret, nil
+}
+
+// cargoPackage returns the root and the name of the package containing the directory `dir`,
+// and the root of the workspace which the package belongs to (the root of the package if none).
+func cargoPackage(dir string) (string, string, string, error) {
+
+	var root, name = "", ""
+	for d := dir; ; d = filepath.Dir(d) {
+		var file = filepath.Join(d, "Cargo.toml")
+		if util.IsFile(file) {
+			var m, err = parseCargoManifest(file)
+			if err != nil {
+				return "", "", "", err
+			}
+			if m.PackageName != "" {
+				root, name = d, m.PackageName
+				if m.IsWorkspace {
+					return root, name, root, nil
+				}
+				break
+			}
+		}
+		if filepath.Dir(d) == d {
+			return "", "", "", errors.New("`Cargo.toml` not found")
+		}
+	}
+
+	//The workspace is the nearest ancestor with `[workspace]`.
+	for d := filepath.Dir(root); filepath.Dir(d) != d; d = filepath.Dir(d) {
+		var file = filepath.Join(d, "Cargo.toml")
+		if !util.IsFile(file) {
+			continue
+		}
+		if m, err := parseCargoManifest(file); (err == nil) && m.IsWorkspace {
+			return root, name, d, nil
+		}
+	}
+	return root, name, root, nil
+
+}
+
+// cargoTarget returns the kind (`bin`, `example` or `test`) and the name of the target of the file `path`
+// in the package `root` named `packageName`, following the layout of Cargo.
+// The kind is empty when the file isn't the root of a target (e.g. a module or the library).
+func cargoTarget(root string, packageName string, path string) (string, string) {
+	var rel, err = filepath.Rel(root, path)
+	if err != nil {
+		return "", ""
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "src/main.rs" {
+		return "bin", packageName
+	}
+	var m = cargoTargetRegexp.FindStringSubmatch(rel)
+	if m == nil {
+		return "", ""
+	}
+	switch m[1] {
+	case "src/bin":
+		return "bin", m[2]
+	case "examples":
+		return "example", m[2]
+	default:
+		return "test", m[2]
+	}
+}
+
+// cargoMessage is the part of a JSON message of Cargo which the runner needs.
+type cargoMessage struct {
+	Reason string `json:"reason"`
+	Target struct {
+		Name string `json:"name"`
+	} `json:"target"`
+	Executable string `json:"executable"` //empty (i.e. null) unless the target is an executable
+}
+
+// cargoArtifactLinker receives the messages of `cargo build --message-format=json-render-diagnostics`, and links `link`
+// to the executable of the target `name`. The path to the executable is taken from Cargo as it depends on e.g. the
+// target directory in `.cargo/config.toml`, `--profile` and `--target`.
+type cargoArtifactLinker struct {
+	name      string
+	link      string
+	errWriter io.Writer
+	buf       []byte
+}
+
+func (l *cargoArtifactLinker) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		var i = bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		var m cargoMessage
+		var err = json.Unmarshal(l.buf[:i], &m)
+		l.buf = l.buf[i+1:]
+		if (err != nil) || (m.Reason != "compiler-artifact") || (m.Target.Name != l.name) || (m.Executable == "") {
+			continue
+		}
+		if err := linkFile(m.Executable, l.link); err != nil {
+			fmt.Fprintf(l.errWriter, "Failed to link the executable: %v\n", err)
+		}
+	}
+	return len(p), nil //Cargo mustn't fail to write the messages.
+}
+
+// linkFile makes `link` a symbolic link to `target`, replacing the old one atomically.
+func linkFile(target string, link string) error {
+	var tmp = link + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, link)
+}
+
+// rustModulePath returns the path of the module which the file `path` in the package at `root` defines,
+// e.g. `foo::bar` for `src/foo/bar.rs` or `src/foo/bar/mod.rs`. It's empty for the crate roots (e.g. `src/lib.rs` and `tests/a.rs`).
+func rustModulePath(root string, path string) string {
+	var rel, err = filepath.Rel(root, path)
+	if (err != nil) || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	var l = strings.Split(filepath.ToSlash(strings.TrimSuffix(rel, ".rs")), "/")
+	switch {
+	case (len(l) >= 3) && (l[0] == "src") && (l[1] == "bin"):
+		l = l[3:] //under `src/bin/<name>.rs` or `src/bin/<name>/main.rs`
+	case (len(l) >= 2) && slices.Contains([]string{"tests", "examples", "benches"}, l[0]):
+		l = l[2:]
+	case (len(l) == 2) && (l[0] == "src") && ((l[1] == "lib") || (l[1] == "main")):
+		return ""
+	case l[0] == "src":
+		l = l[1:]
+	default:
+		return ""
+	}
+	if slices.Equal(l, []string{"main"}) { //the root in its own directory
+		return ""
+	}
+	if (len(l) != 0) && (l[len(l)-1] == "mod") {
+		l = l[:len(l)-1]
+	}
+	return strings.Join(l, "::")
+}
@@ -0,0 +1,135 @@
+package runner
+
+import "os"
+import "path/filepath"
+import "strings"
+import "testing"
+
+func Test_cargoPackage(t *testing.T) {
+
+	var dir = t.TempDir()
+	var manifests = map[string]string{
+		"ws/Cargo.toml":          "[workspace]\nmembers = [\"crates/*\"]\n",
+		"ws/crates/a/Cargo.toml": "# name = \"comment\"\n[package]\nname = \"a-b\"\n\n[dependencies]\nname = \"dependency\"\n",
+		"single/Cargo.toml":      "[package]\nname = \"single\"\n",
+	}
+	for file, contents := range manifests {
+		var path = filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Join(filepath.Dir(path), "src", "bin"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var testCases = []struct {
+		dir       string
+		root      string
+		name      string
+		workspace string
+	}{
+		{"ws/crates/a/src/bin", "ws/crates/a", "a-b", "ws"},
+		{"single/src", "single", "single", "single"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.dir, func(t *testing.T) {
+			var root, name, workspace, err = cargoPackage(filepath.Join(dir, tc.dir))
+			if (err != nil) || (root != filepath.Join(dir, tc.root)) || (name != tc.name) || (workspace != filepath.Join(dir, tc.workspace)) {
+				t.Fatal(root, name, workspace, err)
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		if _, _, _, err := cargoPackage(filepath.Join(dir, "ws")); err == nil { //a virtual manifest only
+			t.FailNow()
+		}
+	})
+
+}
+
+func Test_cargoTarget(t *testing.T) {
+
+	var testCases = []struct {
+		file string
+		kind string
+		name string
+	}{
+		{"src/main.rs", "bin", "pkg"},
+		{"src/bin/tool.rs", "bin", "tool"},
+		{"src/bin/tool/main.rs", "bin", "tool"},
+		{"src/bin/tool/util.rs", "", ""},
+		{"examples/demo.rs", "example", "demo"},
+		{"tests/integration.rs", "test", "integration"},
+		{"src/lib.rs", "", ""},
+		{"src/a/mod.rs", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.file, func(t *testing.T) {
+			var kind, name = cargoTarget("/p", "pkg", filepath.Join("/p", tc.file))
+			if (kind != tc.kind) || (name != tc.name) {
+				t.Fatal(kind, name)
+			}
+		})
+	}
+
+}
+
+func Test_cargoArtifactLinker(t *testing.T) {
+
+	var dir = t.TempDir()
+	var link = filepath.Join(dir, "main.out")
+	var l = &cargoArtifactLinker{name: "e", link: link, errWriter: os.Stderr}
+
+	var messages = strings.Join([]string{
+		`{"reason":"compiler-artifact","target":{"kind":["custom-build"],"name":"build-script-build"},"executable":null}`,
+		`{"reason":"compiler-artifact","target":{"kind":["lib"],"name":"e"},"executable":null}`,
+		`{"reason":"compiler-artifact","target":{"kind":["example"],"name":"e"},"executable":"/w/out/release/examples/e"}`,
+		`{"reason":"build-finished","success":true}`,
+	}, "\n") + "\n"
+	//The messages are written in pieces.
+	for i := 0; i < len(messages); i += 10 {
+		var end = i + 10
+		if end > len(messages) {
+			end = len(messages)
+		}
+		if n, err := l.Write([]byte(messages[i:end])); (err != nil) || (n != end-i) {
+			t.Fatal(n, err)
+		}
+	}
+
+	if target, err := os.Readlink(link); (err != nil) || (target != "/w/out/release/examples/e") {
+		t.Fatal(target, err)
+	}
+
+}
+
+func Test_rustModulePath(t *testing.T) {
+
+	var tests = []struct {
+		path     string
+		expected string
+	}{
+		{"src/lib.rs", ""},
+		{"src/main.rs", ""},
+		{"src/foo.rs", "foo"},
+		{"src/foo/mod.rs", "foo"},
+		{"src/foo/bar.rs", "foo::bar"},
+		{"src/bin/x.rs", ""},
+		{"src/bin/x/main.rs", ""},
+		{"src/bin/x/util.rs", "util"},
+		{"tests/a.rs", ""},
+		{"tests/a/main.rs", ""},
+		{"build.rs", ""},
+	}
+
+	for _, test := range tests {
+		if ret := rustModulePath("/p", filepath.Join("/p", test.path)); ret != test.expected {
+			t.Fatal(test, ret)
+		}
+	}
+
+}
@@ -0,0 +1,121 @@
+package snippet
+
+import "fmt"
+import "io"
+import "os"
+import "path/filepath"
+import "regexp"
+import "strings"
+
+import "executer/cache"
+
+// aliases maps the names of languages to the extensions.
+var aliases = map[string]string{
+	"c++":        "cpp",
+	"cxx":        "cpp",
+	"python":     "py",
+	"ruby":       "rb",
+	"bash":       "sh",
+	"shell":      "sh",
+	"gnuplot":    "gp",
+	"javascript": "js",
+	"typescript": "ts",
+	"haskell":    "hs",
+	"golang":     "go",
+	"rust":       "rs",
+}
+
+// Ext returns the extension of the sources of `lang`, which is either an extension (e.g. `cpp`) or a name (e.g. `rust`).
+func Ext(lang string) string {
+	if ext, ok := aliases[lang]; ok {
+		return ext
+	}
+	return lang
+}
+
+var (
+	javaPublicClassRegexp = regexp.MustCompile(`(?m)^\s*public\s+(?:(?:final|abstract)\s+)*class\s+(\w+)`)
+	javaClassRegexp       = regexp.MustCompile(`(?m)^\s*(?:(?:final|abstract)\s+)*class\s+(\w+)`)
+	haskellModuleRegexp   = regexp.MustCompile(`(?m)^module\s+([\w.]+)`)
+)
+
+// fileName returns the name which the source `content` has to be saved as.
+// Java requires the file to be named after the public class, and GHC looks for modules by their names.
+func fileName(content string, ext string) string {
+	switch ext {
+	case "java":
+		if m := javaPublicClassRegexp.FindStringSubmatch(content); m != nil {
+			return m[1] + ".java"
+		}
+		if m := javaClassRegexp.FindStringSubmatch(content); m != nil {
+			return m[1] + ".java"
+		}
+		return "Main.java"
+	case "hs":
+		if m := haskellModuleRegexp.FindStringSubmatch(content); m != nil {
+			return filepath.Join(strings.Split(m[1], ".")...) + ".hs"
+		}
+		return "Main.hs"
+	case "go":
+		return "main.go"
+	}
+	return "snippet." + ext
+}
+
+// Materialize saves the source read from `r` into a new temporary directory `dir` as a file of `lang`.
+// The runner is to run in `dir`, so that the project files around the current directory (e.g. `go.mod`) don't affect it.
+// `cleanup` removes the directory along with the cache entry of the file.
+func Materialize(r io.Reader, lang string) (dir string, path string, cleanup func(), err error) {
+
+	var ext = Ext(lang)
+
+	var b, err2 = io.ReadAll(r)
+	if err2 != nil {
+		return "", "", nil, err2
+	}
+	var content = string(b)
+
+	dir, err = os.MkdirTemp("", "executer-snippet-")
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	cleanup = func() {
+		if path != "" {
+			os.RemoveAll(cache.Dir(path))
+		}
+		os.RemoveAll(dir)
+	}
+
+	var file = fileName(content, ext)
+	if ext == "rs" {
+		//The Rust runner requires a package.
+		var manifest = "[package]\nname = \"snippet\"\nversion = \"0.1.0\"\nedition = \"2021\"\n"
+		if err := os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte(manifest), 0644); err != nil {
+			cleanup()
+			return "", "", nil, err
+		}
+		file = filepath.Join("src", "main.rs")
+	}
+	if ext == "ts" {
+		//`tsc --build` requires `tsconfig.json`, and the runner executes `target/<name>.js`.
+		var config = fmt.Sprintf("{\"compilerOptions\": {\"outDir\": \"target\"}, \"files\": [%q]}\n", file)
+		if err := os.WriteFile(filepath.Join(dir, "tsconfig.json"), []byte(config), 0644); err != nil {
+			cleanup()
+			return "", "", nil, err
+		}
+	}
+
+	path = filepath.Join(dir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		cleanup()
+		return "", "", nil, err
+	}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		cleanup()
+		return "", "", nil, err
+	}
+
+	return dir, path, cleanup, nil
+
+}
@@ -0,0 +1,61 @@
+package snippet
+
+import "os"
+import "path/filepath"
+import "strings"
+import "testing"
+
+import "executer/util"
+
+func Test_fileName(t *testing.T) {
+
+	var tests = []struct {
+		content  string
+		ext      string
+		expected string
+	}{
+		{"int main() {}", "cpp", "snippet.cpp"},
+		{"class A {}\npublic final class Solver {\n}", "java", "Solver.java"},
+		{"class Main {}", "java", "Main.java"},
+		{"import java.util.*;", "java", "Main.java"},
+		{"module Data.Queue where\n", "hs", "Data/Queue.hs"},
+		{"main = print 1", "hs", "Main.hs"},
+		{"package main", "go", "main.go"},
+	}
+
+	for _, test := range tests {
+		if ret := fileName(test.content, test.ext); ret != test.expected {
+			t.Fatal(test, ret)
+		}
+	}
+
+}
+
+func Test_Materialize(t *testing.T) {
+
+	t.Run("unit file", func(t *testing.T) {
+		var dir, path, cleanup, err = Materialize(strings.NewReader("print(1)\n"), "python")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (filepath.Base(path) != "snippet.py") || (filepath.Dir(path) != dir) || (util.ReadFileUnchecked(path)[0] != "print(1)") {
+			t.Fatal(path)
+		}
+		cleanup()
+		if _, err := os.Stat(dir); err == nil {
+			t.Fatal(dir)
+		}
+	})
+
+	t.Run("rust", func(t *testing.T) {
+		var dir, path, cleanup, err = Materialize(strings.NewReader("fn main() {}\n"), "rs")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+		if !strings.HasSuffix(path, "/src/main.rs") || !util.IsFile(filepath.Join(dir, "Cargo.toml")) {
+			t.Fatal(path)
+		}
+	})
+
+}
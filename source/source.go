@@ -52,3 +52,13 @@ func New(p string) Source {
 	return ret
 
 }
+
+// NewIn is `New` with a relative `p` resolved against `dir` instead of the current directory.
+func NewIn(p string, dir string) Source {
+	if filepath.IsAbs(p) {
+		return New(p)
+	}
+	var ret = New(filepath.Join(dir, p))
+	ret.Original = p
+	return ret
+}
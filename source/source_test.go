@@ -28,4 +28,19 @@ func Test_misc(t *testing.T) {
 
 	})
 
+	t.Run("in a directory", func(t *testing.T) {
+
+		var s = NewIn("src/main.go", "/w")
+		fmt.Println(s)
+
+		if !((s.Original == "src/main.go") && (s.Path == "/w/src/main.go") && (s.Dir == "/w/src") && (s.Name == "main")) {
+			t.FailNow()
+		}
+
+		if s = NewIn("/a/main.go", "/w"); s.Path != "/a/main.go" {
+			t.Fatal(s)
+		}
+
+	})
+
 }
@@ -0,0 +1,141 @@
+package stress
+
+import "fmt"
+import "os"
+import "strconv"
+
+import "executer/cache"
+import "executer/diff"
+import "executer/exec"
+import "executer/judge"
+import "executer/option"
+import "executer/report"
+import "executer/runner"
+import "executer/source"
+import "executer/util"
+
+// build compiles `s` via its runner, recording the compilations to `rep`, and returns the step to execute it.
+// The exit status is non-zero if it fails.
+func build(s source.Source, o option.Options, base exec.Option, isForced bool, rep *report.Report) (exec.Option, int) {
+
+	o.Source = s
+	o.IsOnlyCompileMode = false
+	o.IsOnlyExecuteMode = false
+	o.ShouldMeasureTime = false
+
+	var r, err = runner.Resolve(o, base)
+	if err != nil {
+		util.Eprintln(fmt.Sprintf("Failed to resolve the runner for `%v`: %v", s.Original, err))
+		return exec.Option{}, base.ExitStatusWhenCompileError
+	}
+
+	var steps, program, err2 = r.Program()
+	if err2 != nil {
+		util.Eprintln(fmt.Sprintf("`%v` cannot be used for `stress`: %v", s.Original, err2))
+		return exec.Option{}, base.ExitStatusWhenCompileError
+	}
+
+	for _, step := range steps {
+		var result, status, isCached = cache.Execute(step, isForced)
+		if !isCached {
+			rep.Add(step, result)
+		}
+		if status != 0 {
+			return exec.Option{}, status
+		}
+	}
+
+	return program, 0
+
+}
+
+// Run executes the `stress` subcommand and returns the exit status.
+// Only the runs of the last iteration are recorded to `rep`, as the iterations may continue indefinitely.
+func Run(o option.Options, base exec.Option, d diff.Option, rep *report.Report) int {
+
+	var generator, status = build(o.Generator, option.Options{}, base, o.ShouldForceRebuild, rep)
+	if status != 0 {
+		return status
+	}
+	reference, status := build(o.Reference, option.Options{}, base, o.ShouldForceRebuild, rep)
+	if status != 0 {
+		return status
+	}
+	solution, status := build(o.Source, o, base, o.ShouldForceRebuild, rep)
+	if status != 0 {
+		return status
+	}
+
+	//runs holds the runs of the current iteration until it ends.
+	var runs = make([]report.Command, 0)
+	var run = func(program exec.Option, input []byte) ([]byte, exec.Result, error) {
+		var output, result, err = judge.RunProgram(program, input)
+		if err == nil {
+			runs = append(runs, report.NewCommand(program, result))
+		}
+		return output, result, err
+	}
+	defer func() {
+		rep.Commands = append(rep.Commands, runs...)
+	}()
+
+	var failedInputFile = o.Source.PathWoExt + ".stress.in"
+
+	var fail = func(input []byte, format string, a ...any) int {
+		util.Eprintln("")
+		util.Eprintf("\u001B[091m%v\u001B[0m\n", fmt.Sprintf(format, a...))
+		if err := os.WriteFile(failedInputFile, input, 0644); err != nil {
+			util.Eprintf("Failed to save the input: %v\n", err)
+		} else {
+			util.Eprintf("The input is saved to `%v`.\n", failedInputFile)
+		}
+		return 1
+	}
+
+	for seed := 1; (o.Iterations == 0) || (seed <= o.Iterations); seed++ {
+
+		util.Eprintf("\rIteration #%v", seed)
+		runs = runs[:0]
+
+		var g = generator
+		g.ExecOptions = append([]string{strconv.Itoa(seed)}, g.ExecOptions...)
+		var input, result, err = run(g, nil)
+		if (err != nil) || (result.ExitStatus != 0) {
+			util.Eprintln("")
+			util.Eprintf("The generator failed with seed %v.\n", seed)
+			return 1
+		}
+
+		expected, result, err := run(reference, input)
+		if err != nil {
+			util.Eprintln("")
+			util.Eprintf("Failed to execute the reference: %v\n", err)
+			return 1
+		}
+		if result.ExitStatus != 0 {
+			return fail(input, "The reference exited with status %v. (seed: %v)", result.ExitStatus, seed)
+		}
+
+		actual, result, err := run(solution, input)
+		if err != nil {
+			util.Eprintln("")
+			util.Eprintf("Failed to execute the solution: %v\n", err)
+			return 1
+		}
+		if result.ExitStatus != 0 {
+			return fail(input, "The solution exited with status %v. (seed: %v)", result.ExitStatus, seed)
+		}
+
+		if !judge.Compare(expected, actual) {
+			var ret = fail(input, "Wrong answer. (seed: %v)", seed)
+			judge.PrintMismatch(expected, actual, d)
+			return ret
+		}
+
+	}
+
+	util.Eprintln("")
+	util.Eprintln("\u001B[092mNo counterexample found.\u001B[0m")
+	return 0
+
+}
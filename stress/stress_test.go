@@ -0,0 +1,77 @@
+package stress
+
+import "os"
+import "path/filepath"
+import "strings"
+import "testing"
+
+import "executer/diff"
+import "executer/exec"
+import "executer/option"
+import "executer/report"
+import "executer/source"
+
+func Test_Run(t *testing.T) {
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var dir = t.TempDir()
+	var files = map[string]string{
+		"gen.sh":   "echo $(( $1 % 4 ))\n",
+		"ref.sh":   "read x\necho $(( x * 2 ))\n",
+		"ok.sh":    "read x\necho $(( x + x ))\n",
+		"wa.sh":    "read x\nif [ $x -lt 3 ]; then echo $(( x * 2 )); else echo 0; fi\n",
+		"error.sh": "exit 1\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var run = func(generator string, solution string, iterations int) (int, *report.Report) {
+		var o = option.Options{
+			Source:     source.New(filepath.Join(dir, solution)),
+			Generator:  source.New(filepath.Join(dir, generator)),
+			Reference:  source.New(filepath.Join(dir, "ref.sh")),
+			Iterations: iterations,
+		}
+		var rep = report.New(o.Source.Path, "", "")
+		return Run(o, exec.Option{ExitStatusWhenCompileError: 100}, diff.Option{}, rep), rep
+	}
+
+	t.Run("no counterexample", func(t *testing.T) {
+		var exitStatus, rep = run("gen.sh", "ok.sh", 5)
+		if exitStatus != 0 {
+			t.Fatal(exitStatus)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "ok.stress.in")); err == nil {
+			t.Fatal("The input shouldn't be saved.")
+		}
+		if (len(rep.Commands) != 3) || (rep.Commands[0].Argv[len(rep.Commands[0].Argv)-1] != "5") {
+			t.Fatal(rep.Commands)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		var exitStatus, rep = run("gen.sh", "wa.sh", 0)
+		if exitStatus != 1 {
+			t.Fatal(exitStatus)
+		}
+		var b, err = os.ReadFile(filepath.Join(dir, "wa.stress.in"))
+		if (err != nil) || (strings.TrimSpace(string(b)) != "3") {
+			t.Fatal(string(b), err)
+		}
+		if (len(rep.Commands) != 3) || (rep.Commands[0].Argv[len(rep.Commands[0].Argv)-1] != "3") {
+			t.Fatal(rep.Commands)
+		}
+	})
+
+	t.Run("generator failed", func(t *testing.T) {
+		var exitStatus, rep = run("error.sh", "ok.sh", 5)
+		if (exitStatus != 1) || (len(rep.Commands) != 1) {
+			t.Fatal(exitStatus, rep.Commands)
+		}
+	})
+
+}
@@ -3,8 +3,8 @@ package util
 import "fmt"
 import "os"
 import "io"
-import "errors"
 import "strings"
+import "path/filepath"
 
 func Eprintln[T any](t T) {
 	fmt.Fprintln(os.Stderr, t)
@@ -33,10 +33,8 @@ func ToStringPretty[T any](l []T) string {
 }
 
 func IsFile(path string) bool {
-	if info, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) && !info.IsDir() {
-		return true
-	}
-	return false
+	var info, err = os.Stat(path)
+	return (err == nil) && !info.IsDir()
 }
 
 func ReadFileUnchecked(file string) []string {
@@ -45,3 +43,14 @@ func ReadFileUnchecked(file string) []string {
 	var b, _ = io.ReadAll(f)
 	return strings.Split(string(b), "\n")
 }
+
+// XDGDir returns the directory for executer under the XDG base directory specified by `variable` (e.g. `XDG_CACHE_HOME`).
+// `fallback` is used, relative to the home directory, when the variable isn't set.
+func XDGDir(variable string, fallback string) string {
+	var base = os.Getenv(variable)
+	if base == "" {
+		var home, _ = os.UserHomeDir()
+		base = filepath.Join(home, fallback)
+	}
+	return filepath.Join(base, "executer")
+}
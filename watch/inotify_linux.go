@@ -0,0 +1,128 @@
+//go:build linux
+
+package watch
+
+import "bytes"
+import "os"
+import "path/filepath"
+import "sync"
+import "unsafe"
+
+import "golang.org/x/sys/unix"
+
+import "executer/util"
+
+const inotifyMask = unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO
+
+// inotifyWatcher detects changes with inotify(7).
+type inotifyWatcher struct {
+	fd        int
+	mutex     sync.Mutex
+	dirs      map[int]string //watch descriptor to directory
+	recursive map[string]bool
+	events    chan string
+	done      chan struct{}
+}
+
+func newWatcher(roots []root, isDebugMode bool) (watcher, error) {
+
+	var fd, err = unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		util.DebugPrint("Failed to initialize inotify. Falling back to polling.", isDebugMode)
+		return newPollWatcher(roots), nil
+	}
+
+	var ret = &inotifyWatcher{
+		fd:        fd,
+		dirs:      make(map[int]string),
+		recursive: make(map[string]bool),
+		events:    make(chan string),
+		done:      make(chan struct{}),
+	}
+	for _, r := range roots {
+		for _, dir := range r.dirs() {
+			if err := ret.add(dir, r.IsRecursive); err != nil {
+				unix.Close(fd)
+				return nil, err
+			}
+		}
+	}
+
+	go ret.loop()
+	return ret, nil
+
+}
+
+func (w *inotifyWatcher) add(dir string, isRecursive bool) error {
+	var wd, err = unix.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	w.mutex.Lock()
+	w.dirs[wd] = dir
+	w.recursive[dir] = isRecursive
+	w.mutex.Unlock()
+	return nil
+}
+
+func (w *inotifyWatcher) loop() {
+	defer unix.Close(w.fd)
+	var buf = make([]byte, 64*(unix.SizeofInotifyEvent+unix.PathMax+1))
+	for {
+		//The descriptor is polled with a timeout since closing it doesn't interrupt a blocking read.
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+		var fds = []unix.PollFd{{Fd: int32(w.fd), Events: unix.POLLIN}}
+		if n, err := unix.Poll(fds, 200); (err != nil) || (n == 0) {
+			continue
+		}
+		var n, err = unix.Read(w.fd, buf)
+		if err != nil {
+			if (err == unix.EINTR) || (err == unix.EAGAIN) {
+				continue
+			}
+			return
+		}
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			var e = (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			var name = string(bytes.TrimRight(buf[offset+unix.SizeofInotifyEvent:offset+unix.SizeofInotifyEvent+int(e.Len)], "\x00"))
+			offset += unix.SizeofInotifyEvent + int(e.Len)
+
+			w.mutex.Lock()
+			var dir, ok = w.dirs[int(e.Wd)]
+			var isRecursive = w.recursive[dir]
+			w.mutex.Unlock()
+			if !ok || (name == "") {
+				continue
+			}
+			var path = filepath.Join(dir, name)
+
+			if e.Mask&unix.IN_ISDIR != 0 {
+				if isRecursive && (e.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0) && !isIgnoredDir(name) {
+					for _, d := range (root{Dir: path, IsRecursive: true}).dirs() {
+						w.add(d, true)
+					}
+				}
+				continue
+			}
+
+			select {
+			case w.events <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+func (w *inotifyWatcher) Events() <-chan string {
+	return w.events
+}
+
+func (w *inotifyWatcher) Close() error {
+	close(w.done) //The descriptor is closed by `loop`.
+	return nil
+}
@@ -0,0 +1,86 @@
+package watch
+
+import "os"
+import "path/filepath"
+import "time"
+
+// pollInterval is the interval of the scans of `pollWatcher`.
+const pollInterval = 500 * time.Millisecond
+
+// pollWatcher detects changes by comparing the modification times of the files periodically.
+// This is used where inotify isn't available.
+type pollWatcher struct {
+	roots  []root
+	events chan string
+	done   chan struct{}
+}
+
+func newPollWatcher(roots []root) *pollWatcher {
+	var ret = &pollWatcher{
+		roots:  roots,
+		events: make(chan string),
+		done:   make(chan struct{}),
+	}
+	go ret.loop(ret.scan())
+	return ret
+}
+
+// scan returns the modification times of the files under the roots.
+func (w *pollWatcher) scan() map[string]time.Time {
+	var ret = make(map[string]time.Time)
+	for _, r := range w.roots {
+		for _, dir := range r.dirs() {
+			var l, _ = os.ReadDir(dir)
+			for _, e := range l {
+				if e.IsDir() {
+					continue
+				}
+				if info, err := e.Info(); err == nil {
+					ret[filepath.Join(dir, e.Name())] = info.ModTime()
+				}
+			}
+		}
+	}
+	return ret
+}
+
+func (w *pollWatcher) loop(previous map[string]time.Time) {
+	var ticker = time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+		var current = w.scan()
+		var changed = make([]string, 0)
+		for path, t := range current {
+			if u, ok := previous[path]; !ok || !t.Equal(u) {
+				changed = append(changed, path)
+			}
+		}
+		for path := range previous {
+			if _, ok := current[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+		previous = current
+		for _, path := range changed {
+			select {
+			case w.events <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+func (w *pollWatcher) Events() <-chan string {
+	return w.events
+}
+
+func (w *pollWatcher) Close() error {
+	close(w.done)
+	return nil
+}
@@ -0,0 +1,251 @@
+package watch
+
+import "context"
+import "errors"
+import "fmt"
+import "net"
+import "os"
+import "os/signal"
+import "path/filepath"
+import "strconv"
+import "strings"
+import "syscall"
+import "time"
+
+import "executer/exec"
+import "executer/option"
+import "executer/util"
+
+// debounce is the quiet period waited for after a change, as saving a file in Vim causes a burst of events.
+const debounce = 100 * time.Millisecond
+
+const (
+	initialBackoff    = 500 * time.Millisecond
+	maxBackoff        = 30 * time.Second
+	stableDuration    = 10 * time.Second //The backoff is reset when the program has run longer than this.
+	readyPollInterval = 100 * time.Millisecond
+)
+
+// projectFiles mark the current directory as the root of a project, whose whole tree is watched.
+var projectFiles = []string{
+	"go.mod",
+	"go.work",
+	"Cargo.toml",
+	"package.json",
+	"tsconfig.json",
+	"pubspec.yaml",
+	"settings.gradle",
+	"yrun.sh",
+}
+
+// roots returns the directories to watch for `o`.
+func roots(o option.Options) []root {
+	var cwd, _ = os.Getwd()
+	var isProject = false
+	for _, file := range projectFiles {
+		if util.IsFile(file) {
+			isProject = true
+		}
+	}
+	if l, _ := filepath.Glob("*.cabal"); len(l) != 0 {
+		isProject = true
+	}
+	if !isProject {
+		return []root{{Dir: o.Source.Dir}}
+	}
+	var ret = []root{{Dir: cwd, IsRecursive: true}}
+	if rel, err := filepath.Rel(cwd, o.Source.Dir); (err != nil) || (rel == "..") || strings.HasPrefix(rel, "../") {
+		ret = append(ret, root{Dir: o.Source.Dir})
+	}
+	return ret
+}
+
+// debounced returns the channel which receives the last changed path after `debounce` of quiet.
+// Changes made while the previous one isn't received are merged into it.
+func debounced(events <-chan string) <-chan string {
+	var ret = make(chan string, 1)
+	go func() {
+		for path := range events {
+			if isIgnored(path) {
+				continue
+			}
+			var timer = time.NewTimer(debounce)
+		loop:
+			for {
+				select {
+				case p := <-events:
+					if !isIgnored(p) {
+						path = p
+						timer.Reset(debounce)
+					}
+				case <-timer.C:
+					break loop
+				}
+			}
+			select {
+			case ret <- path:
+			default:
+			}
+		}
+	}()
+	return ret
+}
+
+// waitForPort waits until a TCP connection to `port` on the local host succeeds, and reports whether it did before `ctx` is done.
+func waitForPort(ctx context.Context, port int) bool {
+	var address = net.JoinHostPort("localhost", strconv.Itoa(port))
+	for {
+		if conn, err := net.DialTimeout("tcp", address, time.Second); err == nil {
+			conn.Close()
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
+
+// Run keeps running executer itself with `args` (without `--watch` and `--serve`), and starts it over when a file changes.
+// The run in progress is canceled on a change.
+// With `--serve`, the program is also restarted when it exits, except for compilation errors, with exponential backoff.
+func Run(o option.Options, args []string, base exec.Option, isColored bool) int {
+
+	var self, err = os.Executable()
+	if err != nil {
+		util.Eprintf("Failed to locate the executable: %v\n", err)
+		return 1
+	}
+
+	var argv = make([]string, 0, len(args))
+	for _, arg := range args[1:] {
+		if (arg != "--watch") && (arg != "--serve") {
+			argv = append(argv, arg)
+		}
+	}
+	//`--ready-port` is handled here.
+	for i := 0; i < len(argv); i++ {
+		if (argv[i] == "--ready-port") && (i+1 < len(argv)) {
+			argv = append(argv[:i], argv[i+2:]...)
+			i--
+		}
+	}
+
+	w, err := newWatcher(roots(o), base.IsDebugMode)
+	if err != nil {
+		util.Eprintf("Failed to watch the files: %v\n", err)
+		return 1
+	}
+	defer w.Close()
+	var changes = debounced(w.Events())
+
+	//SIGTERM and SIGQUIT are passed to the program by `exec.Run`, and then we exit as well.
+	var terminated = make(chan os.Signal, 1)
+	signal.Notify(terminated, syscall.SIGTERM, syscall.SIGQUIT)
+	defer signal.Stop(terminated)
+
+	var message = func(s string) {
+		if isColored {
+			util.Eprintf("\u001B[094m%v\u001B[0m\n", s)
+		} else {
+			util.Eprintln(s)
+		}
+	}
+
+	type outcome struct {
+		result exec.Result
+		err    error
+	}
+
+	var backoff = initialBackoff
+
+	for {
+
+		var ctx, cancel = context.WithCancel(context.Background())
+		var start = time.Now()
+		var done = make(chan outcome, 1)
+		go func() {
+			var result, err = exec.Run(exec.Option{
+				Command:     self,
+				Arguments:   argv,
+				Context:     ctx,
+				IsDebugMode: base.IsDebugMode,
+			})
+			done <- outcome{result, err}
+		}()
+
+		if o.ReadyPort != 0 {
+			go func() {
+				if waitForPort(ctx, o.ReadyPort) {
+					message(fmt.Sprintf("Ready on port %v.", o.ReadyPort))
+				}
+			}()
+		}
+
+		var path string
+		select {
+
+		//`exec.Run` returns after the descendants of the canceled executer are killed as well,
+		//so that the old instance doesn't keep e.g. the port which the next one listens on.
+		case path = <-changes:
+			cancel()
+			<-done
+			backoff = initialBackoff
+
+		case r := <-done:
+			cancel()
+			if errors.Is(r.err, exec.ErrInterrupted) {
+				return 130
+			}
+			select {
+			case <-terminated:
+				return r.result.ExitStatus
+			default:
+			}
+
+			var status = "Exited."
+			if r.err != nil {
+				status = fmt.Sprintf("Failed (%v).", r.err)
+			} else if r.result.ExitStatus != 0 {
+				status = fmt.Sprintf("Exited with status %v.", r.result.ExitStatus)
+			}
+
+			if !o.ShouldServe || (r.result.ExitStatus == base.ExitStatusWhenCompileError) {
+				message(fmt.Sprintf("\n%v Waiting for changes...", status))
+				select {
+				case path = <-changes:
+				case <-terminated:
+					return r.result.ExitStatus
+				}
+				backoff = initialBackoff
+				break
+			}
+
+			if time.Since(start) > stableDuration {
+				backoff = initialBackoff
+			}
+			message(fmt.Sprintf("\n%v Restarting in %v...", status, backoff))
+			select {
+			case path = <-changes:
+				backoff = initialBackoff
+			case <-time.After(backoff):
+				backoff *= 2
+				if backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+			case <-terminated:
+				return r.result.ExitStatus
+			}
+
+		}
+
+		if path != "" {
+			message(fmt.Sprintf("\n==================== `%v` changed ====================", path))
+		} else {
+			message("\n==================== restarted ====================")
+		}
+
+	}
+
+}
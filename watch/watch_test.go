@@ -0,0 +1,132 @@
+package watch
+
+import "context"
+import "net"
+import "os"
+import "path/filepath"
+import "runtime"
+import "testing"
+import "time"
+
+func Test_isIgnored(t *testing.T) {
+
+	var tests = []struct {
+		path     string
+		expected bool
+	}{
+		{"/w/main.cpp", false},
+		{"/w/lib.hpp", false},
+		{"/w/main.out", true},
+		{"/w/.main.cpp.swp", true},
+		{"/w/main.cpp~", true},
+		{"/w/4913", true},
+		{"/w/Main.class", true},
+		{"/w/main.stress.in", true},
+	}
+
+	for _, test := range tests {
+		if isIgnored(test.path) != test.expected {
+			t.Fatal(test)
+		}
+	}
+
+}
+
+func Test_watcher(t *testing.T) {
+
+	//A burst of writes results in one notification of the last relevant file.
+	//The polling watcher may split the burst at a scan, so it only has to notify relevant files.
+	var test = func(t *testing.T, w watcher, dir string, isExact bool) {
+
+		defer w.Close()
+		var changes = debounced(w.Events())
+		time.Sleep(100 * time.Millisecond)
+
+		for _, name := range []string{"a.cpp", "b.cpp", ".b.cpp.swp", "b.out"} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var l = make([]string, 0)
+	loop:
+		for {
+			select {
+			case path := <-changes:
+				l = append(l, path)
+			case <-time.After(pollInterval * 3):
+				break loop
+			}
+		}
+
+		if isExact {
+			if (len(l) != 1) || (l[0] != filepath.Join(dir, "b.cpp")) {
+				t.Fatal(l)
+			}
+			return
+		}
+		if len(l) == 0 {
+			t.Fatal("no change detected")
+		}
+		for _, path := range l {
+			if (path != filepath.Join(dir, "a.cpp")) && (path != filepath.Join(dir, "b.cpp")) {
+				t.Fatal(l)
+			}
+		}
+
+	}
+
+	t.Run("default", func(t *testing.T) {
+		var dir = t.TempDir()
+		var w, err = newWatcher([]root{{Dir: dir}}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test(t, w, dir, runtime.GOOS == "linux")
+	})
+
+	t.Run("polling", func(t *testing.T) {
+		var dir = t.TempDir()
+		test(t, newPollWatcher([]root{{Dir: dir}}), dir, false)
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		var dir = t.TempDir()
+		var sub = filepath.Join(dir, "pkg", "sub")
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			t.Fatal(err)
+		}
+		var w, err = newWatcher([]root{{Dir: dir, IsRecursive: true}}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test(t, w, sub, runtime.GOOS == "linux")
+	})
+
+}
+
+func Test_waitForPort(t *testing.T) {
+
+	var l, err = net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var port = l.Addr().(*net.TCPAddr).Port
+
+	t.Run("listening", func(t *testing.T) {
+		if !waitForPort(context.Background(), port) {
+			t.FailNow()
+		}
+	})
+
+	l.Close()
+
+	t.Run("canceled", func(t *testing.T) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		if waitForPort(ctx, port) {
+			t.FailNow()
+		}
+	})
+
+}
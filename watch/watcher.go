@@ -0,0 +1,73 @@
+package watch
+
+import "os"
+import "path/filepath"
+import "strings"
+
+import "golang.org/x/exp/slices"
+
+// watcher notifies the paths of the files created, modified, moved or removed under its roots.
+type watcher interface {
+	Events() <-chan string
+	Close() error
+}
+
+// ignoredDirs are the directories of dependencies and build outputs, which aren't watched.
+var ignoredDirs = []string{
+	"node_modules",
+	"target",
+	"build",
+	"dist-newstyle",
+	"__pycache__",
+}
+
+// ignoredExts are the extensions of the files generated by executer and compilers.
+var ignoredExts = []string{
+	".out",
+	".d",
+	".o",
+	".hi",
+	".class",
+	".pyc",
+}
+
+// isIgnoredDir reports whether the directory `name` is skipped when watching recursively.
+func isIgnoredDir(name string) bool {
+	return strings.HasPrefix(name, ".") || slices.Contains(ignoredDirs, name)
+}
+
+// isIgnored reports whether a change of `path` is irrelevant to the build,
+// e.g. swap files and the `4913` file Vim creates to check if the directory is writable.
+func isIgnored(path string) bool {
+	var name = filepath.Base(path)
+	return strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, "~") ||
+		(name == "4913") ||
+		strings.HasSuffix(name, ".stress.in") ||
+		slices.Contains(ignoredExts, filepath.Ext(name))
+}
+
+// root is a directory to watch.
+type root struct {
+	Dir         string
+	IsRecursive bool
+}
+
+// dirs returns the directories to watch for `r`.
+func (r root) dirs() []string {
+	var ret = []string{r.Dir}
+	if !r.IsRecursive {
+		return ret
+	}
+	filepath.WalkDir(r.Dir, func(path string, d os.DirEntry, err error) error {
+		if (err != nil) || !d.IsDir() || (path == r.Dir) {
+			return nil
+		}
+		if isIgnoredDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		ret = append(ret, path)
+		return nil
+	})
+	return ret
+}
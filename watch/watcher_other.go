@@ -0,0 +1,7 @@
+//go:build !linux
+
+package watch
+
+func newWatcher(roots []root, isDebugMode bool) (watcher, error) {
+	return newPollWatcher(roots), nil
+}